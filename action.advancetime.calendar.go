@@ -0,0 +1,392 @@
+package testkit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/testkit/internal/cron"
+)
+
+// ToWallClockTime returns a TimeAdjustment that advances the virtual clock to
+// the next occurrence of a specific time of day, as read from a wall clock in
+// the given location.
+//
+// If the time of day does not occur on a particular day because it is skipped
+// by a daylight saving transition, the clock is advanced to the instant of the
+// transition. If it occurs twice, the clock is advanced to the first
+// occurrence.
+func ToWallClockTime(hour, minute, second int, loc *time.Location) TimeAdjustment {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 || second < 0 || second > 59 {
+		panic(fmt.Sprintf(
+			"ToWallClockTime(%02d:%02d:%02d): time of day is out of range",
+			hour,
+			minute,
+			second,
+		))
+	}
+
+	if loc == nil {
+		panic("ToWallClockTime(): location must not be nil")
+	}
+
+	return toWallClockTime{hour, minute, second, loc}
+}
+
+// ToNextWeekday returns a TimeAdjustment that advances the virtual clock to
+// the start of the next day that falls on the given day of the week, in the
+// given location.
+//
+// The clock is always advanced by at least one day, even if the current day
+// falls on the given day of the week.
+func ToNextWeekday(d time.Weekday, loc *time.Location) TimeAdjustment {
+	if d < time.Sunday || d > time.Saturday {
+		panic(fmt.Sprintf("ToNextWeekday(%d): day of the week is out of range", d))
+	}
+
+	if loc == nil {
+		panic("ToNextWeekday(): location must not be nil")
+	}
+
+	return toNextWeekday{d, loc}
+}
+
+// ToNextBusinessDay returns a TimeAdjustment that advances the virtual clock
+// to the start of the next business day in the given location.
+//
+// A business day is any Monday to Friday that is not a holiday according to
+// cal. If cal is nil, there are no holidays.
+//
+// The clock is always advanced by at least one day, even if the current day is
+// a business day.
+func ToNextBusinessDay(cal HolidayCalendar, loc *time.Location) TimeAdjustment {
+	if loc == nil {
+		panic("ToNextBusinessDay(): location must not be nil")
+	}
+
+	return toNextBusinessDay{cal, loc}
+}
+
+// ByCalendarMonths returns a TimeAdjustment that advances the virtual clock by
+// a number of calendar months, as observed in the given location.
+//
+// The time of day is preserved. If the day of the month does not occur in the
+// resulting month, the last day of that month is used instead. For example,
+// advancing January 31st by one month results in the last day of February.
+func ByCalendarMonths(n int, loc *time.Location) TimeAdjustment {
+	if n < 0 {
+		panic(fmt.Sprintf("ByCalendarMonths(%d): number of months must not be negative", n))
+	}
+
+	if loc == nil {
+		panic("ByCalendarMonths(): location must not be nil")
+	}
+
+	return byCalendarMonths{n, loc}
+}
+
+// ToNextCronMatch returns a TimeAdjustment that advances the virtual clock to
+// the next time that matches a cron expression, as read from a wall clock in
+// the given location.
+//
+// expr is a standard five-field cron expression, consisting of the minute,
+// hour, day-of-month, month and day-of-week fields. Months and days of the
+// week may be given by their three-letter English names. The @yearly,
+// @monthly, @weekly, @daily and @hourly macros are also supported.
+//
+// Times that are skipped by a daylight saving transition match at the instant
+// of the transition. Times that occur twice only match on their first
+// occurrence.
+//
+// It panics if expr is not a valid cron expression.
+func ToNextCronMatch(expr string, loc *time.Location) TimeAdjustment {
+	s, err := cron.Parse(expr)
+	if err != nil {
+		panic(fmt.Sprintf("ToNextCronMatch(%q): %s", expr, err))
+	}
+
+	if loc == nil {
+		panic(fmt.Sprintf("ToNextCronMatch(%q): location must not be nil", expr))
+	}
+
+	return toNextCronMatch{expr, s, loc}
+}
+
+// HolidayCalendar is an interface for determining which days are holidays.
+//
+// It is used by ToNextBusinessDay() to skip days that would otherwise be
+// business days.
+type HolidayCalendar interface {
+	// IsHoliday returns true if the given day is a holiday.
+	//
+	// day is the start of the day in the location that was passed to
+	// ToNextBusinessDay().
+	IsHoliday(day time.Time) bool
+}
+
+// HolidayCalendarFunc is an adaptor to allow the use of a regular function as
+// a HolidayCalendar.
+type HolidayCalendarFunc func(day time.Time) bool
+
+// IsHoliday returns fn(day).
+func (fn HolidayCalendarFunc) IsHoliday(day time.Time) bool {
+	return fn(day)
+}
+
+// Holidays returns a HolidayCalendar that contains a fixed set of dates.
+//
+// Only the year, month and day of each date is significant, as observed in
+// the date's own location.
+func Holidays(dates ...time.Time) HolidayCalendar {
+	type date struct {
+		y int
+		m time.Month
+		d int
+	}
+
+	set := map[date]struct{}{}
+	for _, t := range dates {
+		y, m, d := t.Date()
+		set[date{y, m, d}] = struct{}{}
+	}
+
+	return HolidayCalendarFunc(func(day time.Time) bool {
+		y, m, d := day.Date()
+		_, ok := set[date{y, m, d}]
+		return ok
+	})
+}
+
+// toWallClockTime is a TimeAdjustment that advances the clock to the next
+// occurrence of a specific time of day.
+type toWallClockTime struct {
+	hour, minute, second int
+	loc                  *time.Location
+}
+
+func (a toWallClockTime) Description() string {
+	return fmt.Sprintf(
+		"to the next %02d:%02d:%02d in %s",
+		a.hour,
+		a.minute,
+		a.second,
+		a.loc,
+	)
+}
+
+func (a toWallClockTime) Step(t time.Time) time.Time {
+	w := wallClock(t, a.loc)
+
+	for d := 0; ; d++ {
+		next := fromWallClock(
+			time.Date(w.Year(), w.Month(), w.Day()+d, a.hour, a.minute, a.second, 0, time.UTC),
+			a.loc,
+		)
+
+		if next.After(t) {
+			return next
+		}
+	}
+}
+
+// toNextWeekday is a TimeAdjustment that advances the clock to the start of
+// the next day that falls on a specific day of the week.
+type toNextWeekday struct {
+	day time.Weekday
+	loc *time.Location
+}
+
+func (a toNextWeekday) Description() string {
+	return fmt.Sprintf(
+		"to the start of the next %s in %s",
+		a.day,
+		a.loc,
+	)
+}
+
+func (a toNextWeekday) Step(t time.Time) time.Time {
+	w := wallClock(t, a.loc)
+	n := (int(a.day)-int(w.Weekday())+6)%7 + 1
+
+	return fromWallClock(
+		time.Date(w.Year(), w.Month(), w.Day()+n, 0, 0, 0, 0, time.UTC),
+		a.loc,
+	)
+}
+
+// toNextBusinessDay is a TimeAdjustment that advances the clock to the start
+// of the next business day.
+type toNextBusinessDay struct {
+	cal HolidayCalendar
+	loc *time.Location
+}
+
+func (a toNextBusinessDay) Description() string {
+	return fmt.Sprintf(
+		"to the start of the next business day in %s",
+		a.loc,
+	)
+}
+
+func (a toNextBusinessDay) Step(t time.Time) time.Time {
+	w := wallClock(t, a.loc)
+
+	// Search at most one year ahead so that a calendar that marks every day as
+	// a holiday does not cause the test to hang.
+	for n := 1; n <= 366; n++ {
+		d := time.Date(w.Year(), w.Month(), w.Day()+n, 0, 0, 0, 0, time.UTC)
+
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+
+		next := fromWallClock(d, a.loc)
+
+		if a.cal != nil && a.cal.IsHoliday(next) {
+			continue
+		}
+
+		return next
+	}
+
+	panic(fmt.Sprintf(
+		"cannot adjust the clock %s, the holiday calendar has no business days within a year of %s",
+		a.Description(),
+		t.Format(time.RFC3339),
+	))
+}
+
+// byCalendarMonths is a TimeAdjustment that advances the clock by a number of
+// calendar months.
+type byCalendarMonths struct {
+	months int
+	loc    *time.Location
+}
+
+func (a byCalendarMonths) Description() string {
+	if a.months == 1 {
+		return fmt.Sprintf("by 1 calendar month in %s", a.loc)
+	}
+
+	return fmt.Sprintf("by %d calendar months in %s", a.months, a.loc)
+}
+
+func (a byCalendarMonths) Step(t time.Time) time.Time {
+	if a.months == 0 {
+		return t
+	}
+
+	w := wallClock(t, a.loc)
+
+	// Find the first day of the target month, then clamp the day of the month
+	// to the number of days in that month.
+	first := time.Date(w.Year(), w.Month()+time.Month(a.months), 1, 0, 0, 0, 0, time.UTC)
+	days := first.AddDate(0, 1, -1).Day()
+
+	return fromWallClock(
+		time.Date(
+			first.Year(),
+			first.Month(),
+			min(w.Day(), days),
+			w.Hour(),
+			w.Minute(),
+			w.Second(),
+			w.Nanosecond(),
+			time.UTC,
+		),
+		a.loc,
+	)
+}
+
+// toNextCronMatch is a TimeAdjustment that advances the clock to the next time
+// that matches a cron expression.
+type toNextCronMatch struct {
+	expr     string
+	schedule cron.Schedule
+	loc      *time.Location
+}
+
+func (a toNextCronMatch) Description() string {
+	return fmt.Sprintf(
+		"to the next match of %q in %s",
+		a.expr,
+		a.loc,
+	)
+}
+
+func (a toNextCronMatch) Step(t time.Time) time.Time {
+	w := wallClock(t, a.loc)
+
+	for {
+		// Several wall-clock times may map to the same instant, or to an
+		// instant that has already passed, when they are near a daylight
+		// saving transition.
+		w = a.schedule.Next(w)
+
+		if next := fromWallClock(w, a.loc); next.After(t) {
+			return next
+		}
+	}
+}
+
+// wallClock returns the time shown by a wall clock in loc at the instant t.
+//
+// The result is represented in UTC so that calendar arithmetic performed on it
+// is not affected by daylight saving transitions.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+
+	return time.Date(
+		t.Year(),
+		t.Month(),
+		t.Day(),
+		t.Hour(),
+		t.Minute(),
+		t.Second(),
+		t.Nanosecond(),
+		time.UTC,
+	)
+}
+
+// fromWallClock returns the first instant at which a wall clock in loc reaches
+// the time w, which is represented in UTC.
+//
+// If w is skipped by a daylight saving transition, the result is the instant
+// of the transition. If w occurs twice, the result is its first occurrence.
+func fromWallClock(w time.Time, loc *time.Location) time.Time {
+	t := time.Date(
+		w.Year(),
+		w.Month(),
+		w.Day(),
+		w.Hour(),
+		w.Minute(),
+		w.Second(),
+		w.Nanosecond(),
+		loc,
+	)
+
+	start, end := t.ZoneBounds()
+
+	switch c := wallClock(t, loc); {
+	case c.After(w):
+		// The wall clock jumped past w when t's zone came into effect.
+		return start
+	case c.Before(w):
+		// The wall clock jumps past w when t's zone goes out of effect.
+		return end
+	}
+
+	if start.IsZero() {
+		return t
+	}
+
+	// If the clock was turned back when t's zone came into effect, w may also
+	// have occurred in the preceding zone.
+	_, offset := t.Zone()
+	_, prevOffset := start.Add(-1).Zone()
+
+	if prev := t.Add(time.Duration(offset-prevOffset) * time.Second); prev.Before(t) && wallClock(prev, loc).Equal(w) {
+		return prev
+	}
+
+	return t
+}
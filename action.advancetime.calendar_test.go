@@ -0,0 +1,321 @@
+package testkit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestCalendarTimeAdjustments(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// In 2021, New York's clocks went forward from 02:00 EST to 03:00 EDT on
+	// March 14th, and back from 02:00 EDT to 01:00 EST on November 7th.
+	date := func(y int, m time.Month, d, h, min int, loc *time.Location) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+
+	type step struct {
+		Name   string
+		Adj    TimeAdjustment
+		Before time.Time
+		After  time.Time
+	}
+
+	run := func(t *testing.T, cases []step) {
+		t.Helper()
+
+		for _, c := range cases {
+			t.Run(c.Name, func(t *testing.T) {
+				xtesting.Expect(
+					t,
+					"unexpected time",
+					c.Adj.Step(c.Before).UTC(),
+					c.After.UTC(),
+				)
+			})
+		}
+	}
+
+	t.Run("func ToWallClockTime()", func(t *testing.T) {
+		run(t, []step{
+			{
+				"it advances the clock to a later time on the same day",
+				ToWallClockTime(9, 0, 0, time.UTC),
+				date(2021, 1, 1, 8, 0, time.UTC),
+				date(2021, 1, 1, 9, 0, time.UTC),
+			},
+			{
+				"it advances the clock to the next day if the time has passed",
+				ToWallClockTime(9, 0, 0, time.UTC),
+				date(2021, 1, 1, 9, 0, time.UTC),
+				date(2021, 1, 2, 9, 0, time.UTC),
+			},
+			{
+				"it preserves the wall-clock time across a daylight saving transition",
+				ToWallClockTime(9, 0, 0, ny),
+				date(2021, 3, 13, 10, 0, ny),
+				date(2021, 3, 14, 9, 0, ny),
+			},
+			{
+				"it advances the clock to the transition if the time is skipped",
+				ToWallClockTime(2, 30, 0, ny),
+				date(2021, 3, 13, 12, 0, ny),
+				date(2021, 3, 14, 7, 0, time.UTC),
+			},
+			{
+				"it advances the clock to the first occurrence of a repeated time",
+				ToWallClockTime(1, 30, 0, ny),
+				date(2021, 11, 7, 0, 0, ny),
+				date(2021, 11, 7, 5, 30, time.UTC),
+			},
+			{
+				"it does not advance the clock to the second occurrence of a repeated time",
+				ToWallClockTime(1, 30, 0, ny),
+				date(2021, 11, 7, 5, 30, time.UTC),
+				date(2021, 11, 8, 1, 30, ny),
+			},
+		})
+
+		t.Run("it produces the expected description", func(t *testing.T) {
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ToWallClockTime(9, 5, 0, ny).Description(),
+				"to the next 09:05:00 in America/New_York",
+			)
+		})
+
+		t.Run("it panics if the time of day is out of range", func(t *testing.T) {
+			xtesting.ExpectPanic(
+				t,
+				"ToWallClockTime(24:00:00): time of day is out of range",
+				func() {
+					ToWallClockTime(24, 0, 0, time.UTC)
+				},
+			)
+		})
+	})
+
+	t.Run("func ToNextWeekday()", func(t *testing.T) {
+		run(t, []step{
+			{
+				"it advances the clock to the start of the next matching day",
+				ToNextWeekday(time.Monday, ny),
+				date(2021, 3, 13, 12, 0, ny), // Saturday
+				date(2021, 3, 15, 0, 0, ny),
+			},
+			{
+				"it advances the clock by a week if the current day matches",
+				ToNextWeekday(time.Monday, ny),
+				date(2021, 3, 15, 12, 0, ny),
+				date(2021, 3, 22, 0, 0, ny),
+			},
+		})
+
+		t.Run("it produces the expected description", func(t *testing.T) {
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ToNextWeekday(time.Monday, time.UTC).Description(),
+				"to the start of the next Monday in UTC",
+			)
+		})
+	})
+
+	t.Run("func ToNextBusinessDay()", func(t *testing.T) {
+		holidays := Holidays(
+			date(2021, 12, 24, 0, 0, time.UTC),
+			date(2021, 12, 27, 0, 0, time.UTC),
+		)
+
+		run(t, []step{
+			{
+				"it advances the clock to the start of the next day",
+				ToNextBusinessDay(nil, ny),
+				date(2021, 12, 22, 12, 0, ny), // Wednesday
+				date(2021, 12, 23, 0, 0, ny),
+			},
+			{
+				"it skips weekends",
+				ToNextBusinessDay(nil, ny),
+				date(2021, 12, 24, 12, 0, ny), // Friday
+				date(2021, 12, 27, 0, 0, ny),
+			},
+			{
+				"it skips holidays",
+				ToNextBusinessDay(holidays, ny),
+				date(2021, 12, 23, 12, 0, ny), // Thursday
+				date(2021, 12, 28, 0, 0, ny),
+			},
+		})
+
+		t.Run("it produces the expected description", func(t *testing.T) {
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ToNextBusinessDay(nil, ny).Description(),
+				"to the start of the next business day in America/New_York",
+			)
+		})
+
+		t.Run("it panics if there are no business days within a year", func(t *testing.T) {
+			adj := ToNextBusinessDay(
+				HolidayCalendarFunc(func(time.Time) bool { return true }),
+				time.UTC,
+			)
+
+			xtesting.ExpectPanic(
+				t,
+				"cannot adjust the clock to the start of the next business day in UTC, the holiday calendar has no business days within a year of 2021-01-01T00:00:00Z",
+				func() {
+					adj.Step(date(2021, 1, 1, 0, 0, time.UTC))
+				},
+			)
+		})
+	})
+
+	t.Run("func ByCalendarMonths()", func(t *testing.T) {
+		run(t, []step{
+			{
+				"it advances the clock by whole months",
+				ByCalendarMonths(13, time.UTC),
+				date(2020, 1, 15, 10, 0, time.UTC),
+				date(2021, 2, 15, 10, 0, time.UTC),
+			},
+			{
+				"it uses the last day of the month if the day does not occur",
+				ByCalendarMonths(1, time.UTC),
+				date(2021, 1, 31, 10, 0, time.UTC),
+				date(2021, 2, 28, 10, 0, time.UTC),
+			},
+			{
+				"it preserves the wall-clock time across a daylight saving transition",
+				ByCalendarMonths(1, ny),
+				date(2021, 2, 14, 9, 0, ny),
+				date(2021, 3, 14, 9, 0, ny),
+			},
+		})
+
+		t.Run("it produces the expected description", func(t *testing.T) {
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ByCalendarMonths(1, time.UTC).Description(),
+				"by 1 calendar month in UTC",
+			)
+
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ByCalendarMonths(3, time.UTC).Description(),
+				"by 3 calendar months in UTC",
+			)
+		})
+
+		t.Run("it panics if the number of months is negative", func(t *testing.T) {
+			xtesting.ExpectPanic(
+				t,
+				"ByCalendarMonths(-1): number of months must not be negative",
+				func() {
+					ByCalendarMonths(-1, time.UTC)
+				},
+			)
+		})
+	})
+
+	t.Run("func ToNextCronMatch()", func(t *testing.T) {
+		run(t, []step{
+			{
+				"it advances the clock to the next matching time",
+				ToNextCronMatch("30 9 * * MON-FRI", ny),
+				date(2021, 3, 13, 12, 0, ny), // Saturday
+				date(2021, 3, 15, 9, 30, ny),
+			},
+			{
+				"it advances the clock to the transition if the time is skipped",
+				ToNextCronMatch("30 2 * * *", ny),
+				date(2021, 3, 14, 1, 0, ny),
+				date(2021, 3, 14, 7, 0, time.UTC),
+			},
+			{
+				"it does not match repeated times a second time",
+				ToNextCronMatch("*/30 * * * *", ny),
+				date(2021, 11, 7, 5, 30, time.UTC), // 01:30 EDT
+				date(2021, 11, 7, 2, 0, ny),
+			},
+		})
+
+		t.Run("it produces the expected description", func(t *testing.T) {
+			xtesting.Expect(
+				t,
+				"unexpected description",
+				ToNextCronMatch("0 0 1 * *", time.UTC).Description(),
+				`to the next match of "0 0 1 * *" in UTC`,
+			)
+		})
+
+		t.Run("it panics if the expression is invalid", func(t *testing.T) {
+			xtesting.ExpectPanic(
+				t,
+				`ToNextCronMatch("* * *"): expected 5 fields, got 3`,
+				func() {
+					ToNextCronMatch("* * *", time.UTC)
+				},
+			)
+		})
+	})
+
+	t.Run("it can be used with AdvanceTime()", func(t *testing.T) {
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "140ca29b-7a05-4f26-968b-6285255e6d8a")
+			},
+		}
+
+		tm := &testingmock.T{}
+		buf := &fact.Buffer{}
+
+		Begin(
+			tm,
+			app,
+			StartTimeAt(date(2021, 1, 31, 10, 0, ny)),
+			WithUnsafeOperationOptions(
+				engine.WithObserver(buf),
+			),
+		).Prepare(
+			AdvanceTime(ByCalendarMonths(1, ny)),
+		)
+
+		xtesting.ExpectContains(
+			t,
+			"expected caption",
+			tm.Logs,
+			"--- advancing time by 1 calendar month in America/New_York ---",
+		)
+
+		var engineTime time.Time
+		for _, f := range buf.Facts() {
+			if f, ok := f.(fact.TickCycleBegun); ok {
+				engineTime = f.EngineTime
+			}
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected engine time",
+			engineTime.UTC(),
+			date(2021, 2, 28, 15, 0, time.UTC),
+		)
+	})
+}
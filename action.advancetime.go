@@ -3,6 +3,7 @@ package testkit
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/dogmatiq/testkit/engine"
@@ -19,18 +20,61 @@ import (
 // It accepts a TimeAdjustment which calculates the amount of time that the
 // clock is advanced.
 //
-// There are two built-in adjustment types; ToTime() and ByDuration(). Users may
-// provide their own TimeAdjustment implementations that model time-related
-// concepts within the application's business domain.
-func AdvanceTime(adj TimeAdjustment) Action {
+// The built-in adjustment types are ToTime(), ByDuration(), ToNextDeadline()
+// and ThroughAllDeadlines(), along with the calendar-aware ToWallClockTime(),
+// ToNextWeekday(), ToNextBusinessDay(), ByCalendarMonths() and
+// ToNextCronMatch(). Users may provide their own TimeAdjustment
+// implementations that model time-related concepts within the application's
+// business domain.
+//
+// By default, the clock is advanced directly to the adjusted time, and the
+// engine performs a single tick. Use the StepThroughDeadlines() option to
+// perform a tick at the scheduled time of each intermediate deadline.
+func AdvanceTime(adj TimeAdjustment, options ...AdvanceTimeOption) Action {
 	if adj == nil {
 		panic("AdvanceTime(<nil>): adjustment must not be nil")
 	}
 
-	return advanceTimeAction{
-		adj,
-		location.OfCall(),
+	act := advanceTimeAction{
+		adj: adj,
+		loc: location.OfCall(),
+	}
+
+	for _, opt := range options {
+		opt.applyAdvanceTimeOption(&act)
 	}
+
+	return act
+}
+
+// AdvanceTimeOption applies optional settings to an AdvanceTime action.
+type AdvanceTimeOption interface {
+	applyAdvanceTimeOption(*advanceTimeAction)
+}
+
+// advanceTimeOptionFunc is an AdvanceTimeOption implemented as a function.
+type advanceTimeOptionFunc func(*advanceTimeAction)
+
+func (f advanceTimeOptionFunc) applyAdvanceTimeOption(a *advanceTimeAction) {
+	f(a)
+}
+
+// StepThroughDeadlines returns an option that causes AdvanceTime() to advance
+// the clock in steps, performing an engine tick at the scheduled time of each
+// deadline that falls before the adjusted time.
+//
+// Each deadline is handled with the engine time equal to the time it is
+// scheduled for, rather than the adjusted time. Deadlines that are scheduled
+// while the clock is being advanced are also handled at their scheduled time,
+// provided it is after the time of the step at which they are scheduled.
+//
+// This mirrors the way deadlines are delivered in production, such that process
+// logic that compares the current time with a deadline's scheduled time behaves
+// as it would in production.
+func StepThroughDeadlines() AdvanceTimeOption {
+	return advanceTimeOptionFunc(func(a *advanceTimeAction) {
+		a.stepThroughDeadlines = true
+	})
 }
 
 // A TimeAdjustment describes a change to the test's virtual clock.
@@ -46,6 +90,11 @@ type TimeAdjustment interface {
 	// of the adjustment.
 	//
 	// t is the virtual clock's current time.
+	//
+	// The adjustments returned by ToNextDeadline() and ThroughAllDeadlines()
+	// depend on the deadlines that are pending within the engine, and so can
+	// only be used with AdvanceTime(). Their Step() methods panic if called
+	// directly.
 	Step(t time.Time) time.Time
 }
 
@@ -65,14 +114,65 @@ func ByDuration(d time.Duration) TimeAdjustment {
 	return byDuration(d)
 }
 
+// ToNextDeadline returns a TimeAdjustment that advances the virtual clock to the
+// time at which the next pending deadline message is scheduled.
+//
+// The deadline is then handled by the engine, along with any other deadlines
+// that are scheduled for the same time.
+//
+// The adjustment depends on the state of the engine, so it can only be used
+// with AdvanceTime(), and its Step() method panics if called directly. The
+// action fails if there are no pending deadlines. Deadlines scheduled by
+// disabled processes are ignored. Use Test.PendingDeadlines() to inspect the
+// pending deadlines.
+func ToNextDeadline() TimeAdjustment {
+	return toNextDeadline{}
+}
+
+// ThroughAllDeadlines returns a TimeAdjustment that advances the virtual clock
+// to the time at which the last pending deadline message is scheduled.
+//
+// All of the deadlines that are pending when the clock is advanced are then
+// handled by the engine.
+//
+// The adjustment depends on the state of the engine, so it can only be used
+// with AdvanceTime(), and its Step() method panics if called directly. The
+// action fails if there are no pending deadlines. Deadlines scheduled by
+// disabled processes are ignored. Use Test.PendingDeadlines() to inspect the
+// pending deadlines.
+func ThroughAllDeadlines() TimeAdjustment {
+	return throughAllDeadlines{}
+}
+
+// deadlineAdjustment is a TimeAdjustment that depends on the deadlines that
+// are pending within the engine.
+type deadlineAdjustment interface {
+	TimeAdjustment
+
+	// stepToDeadline returns the time that the virtual clock should be set to
+	// as a result of the adjustment.
+	//
+	// t is the virtual clock's current time. deadlines is the non-empty list
+	// of pending deadlines, in the order they are scheduled for.
+	stepToDeadline(t time.Time, deadlines []engine.PendingDeadline) time.Time
+}
+
 // advanceTimeAction is an implementation of Action that advances the virtual
 // clock.
 type advanceTimeAction struct {
-	adj TimeAdjustment
-	loc location.Location
+	adj                  TimeAdjustment
+	loc                  location.Location
+	stepThroughDeadlines bool
 }
 
 func (a advanceTimeAction) Caption() string {
+	if a.stepThroughDeadlines {
+		return fmt.Sprintf(
+			"advancing time %s, stepping through deadlines",
+			a.adj.Description(),
+		)
+	}
+
 	return fmt.Sprintf(
 		"advancing time %s",
 		a.adj.Description(),
@@ -87,7 +187,22 @@ func (a advanceTimeAction) ConfigurePredicate(*PredicateOptions) {
 }
 
 func (a advanceTimeAction) Do(ctx context.Context, s ActionScope) error {
-	now := a.adj.Step(*s.VirtualClock)
+	var now time.Time
+
+	if adj, ok := a.adj.(deadlineAdjustment); ok {
+		deadlines := s.Engine.PendingDeadlines(s.OperationOptions...)
+
+		if len(deadlines) == 0 {
+			return fmt.Errorf(
+				"cannot adjust the clock %s, there are no pending deadlines",
+				a.adj.Description(),
+			)
+		}
+
+		now = adj.stepToDeadline(*s.VirtualClock, deadlines)
+	} else {
+		now = a.adj.Step(*s.VirtualClock)
+	}
 
 	if now.Before(*s.VirtualClock) {
 		return fmt.Errorf(
@@ -96,17 +211,54 @@ func (a advanceTimeAction) Do(ctx context.Context, s ActionScope) error {
 		)
 	}
 
-	*s.VirtualClock = now
+	for {
+		next := now
+
+		if a.stepThroughDeadlines {
+			if t, ok := nextDeadline(s, *s.VirtualClock, now); ok {
+				next = t
+			}
+		}
+
+		if err := tick(ctx, s, next); err != nil {
+			return err
+		}
+
+		if next.Equal(now) {
+			return nil
+		}
+	}
+}
+
+// tick sets the virtual clock to t, then performs an engine tick.
+func tick(ctx context.Context, s ActionScope, t time.Time) error {
+	*s.VirtualClock = t
 
 	// There is already an engine.WithCurrentTime() based on the virtual clock
 	// in options slice. Because we have just updated the clock we need to
 	// override it for this one engine tick.
-	s.OperationOptions = append(
-		s.OperationOptions,
-		engine.WithCurrentTime(now),
+	options := append(
+		slices.Clip(s.OperationOptions),
+		engine.WithCurrentTime(t),
 	)
 
-	return s.Engine.Tick(ctx, s.OperationOptions...)
+	return s.Engine.Tick(ctx, options...)
+}
+
+// nextDeadline returns the scheduled time of the earliest pending deadline
+// that is scheduled after the time given by after, ignoring deadlines of
+// processes that are disabled by the action's operation options.
+//
+// ok is false if there is no such deadline, or if it is not scheduled before
+// the time given by before.
+func nextDeadline(s ActionScope, after, before time.Time) (t time.Time, ok bool) {
+	for _, d := range s.Engine.PendingDeadlines(s.OperationOptions...) {
+		if d.ScheduledFor.After(after) {
+			return d.ScheduledFor, d.ScheduledFor.Before(before)
+		}
+	}
+
+	return time.Time{}, false
 }
 
 // toTime is a ClockMutation that advances the clock to a specific time.
@@ -136,3 +288,49 @@ func (d byDuration) Description() string {
 func (d byDuration) Step(before time.Time) time.Time {
 	return before.Add(time.Duration(d))
 }
+
+// toNextDeadline is a TimeAdjustment that advances the clock to the next
+// pending deadline.
+type toNextDeadline struct{}
+
+func (toNextDeadline) Description() string {
+	return "to the next deadline"
+}
+
+func (toNextDeadline) Step(time.Time) time.Time {
+	panic("ToNextDeadline(): the adjustment depends on the engine's pending deadlines, it can only be used with AdvanceTime()")
+}
+
+func (toNextDeadline) stepToDeadline(
+	t time.Time,
+	deadlines []engine.PendingDeadline,
+) time.Time {
+	return latest(t, deadlines[0].ScheduledFor)
+}
+
+// throughAllDeadlines is a TimeAdjustment that advances the clock to the last
+// pending deadline.
+type throughAllDeadlines struct{}
+
+func (throughAllDeadlines) Description() string {
+	return "through all pending deadlines"
+}
+
+func (throughAllDeadlines) Step(time.Time) time.Time {
+	panic("ThroughAllDeadlines(): the adjustment depends on the engine's pending deadlines, it can only be used with AdvanceTime()")
+}
+
+func (throughAllDeadlines) stepToDeadline(
+	t time.Time,
+	deadlines []engine.PendingDeadline,
+) time.Time {
+	return latest(t, deadlines[len(deadlines)-1].ScheduledFor)
+}
+
+// latest returns the later of a and b.
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -1,6 +1,7 @@
 package testkit_test
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -40,6 +41,68 @@ func TestAdvanceTime(t *testing.T) {
 		return tm, startTime, buf, tc
 	}
 
+	// newDeadlineFixture returns a test with a process that schedules two
+	// deadlines, one and two hours after the start time, and a pointer to the
+	// list of engine times at which the deadlines are handled.
+	//
+	// When the first deadline is handled, the process schedules a third
+	// deadline 30 minutes later.
+	newDeadlineFixture := func() (*testingmock.T, time.Time, *[]time.Time, *Test) {
+		startTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+		var handledAt []time.Time
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "140ca29b-7a05-4f26-968b-6285255e6d8a")
+				c.Routes(
+					dogma.ViaProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
+						ConfigureFunc: func(c dogma.ProcessConfigurer) {
+							c.Identity("<process>", "5a0f5e0e-08e4-4e5c-9b7f-0f3fd2f1d7b1")
+							c.Routes(
+								dogma.HandlesEvent[*EventStub[TypeA]](),
+								dogma.ExecutesCommand[*CommandStub[TypeA]](),
+								dogma.SchedulesDeadline[*DeadlineStub[TypeA]](),
+							)
+						},
+						RouteEventToInstanceFunc: func(context.Context, dogma.Event) (string, bool, error) {
+							return "<instance>", true, nil
+						},
+						HandleEventFunc: func(
+							_ context.Context,
+							_ *ProcessRootStub,
+							s dogma.ProcessEventScope[*ProcessRootStub],
+							_ dogma.Event,
+						) error {
+							s.ScheduleDeadline(DeadlineA1, startTime.Add(1*time.Hour))
+							s.ScheduleDeadline(DeadlineA2, startTime.Add(2*time.Hour))
+							return nil
+						},
+						HandleDeadlineFunc: func(
+							_ context.Context,
+							_ *ProcessRootStub,
+							s dogma.ProcessDeadlineScope[*ProcessRootStub],
+							m dogma.Deadline,
+						) error {
+							handledAt = append(handledAt, s.Now())
+
+							if m == DeadlineA1 {
+								s.ScheduleDeadline(DeadlineA3, s.Now().Add(30*time.Minute))
+							}
+
+							return nil
+						},
+					}),
+				)
+			},
+		}
+
+		tm := &testingmock.T{}
+		tc := Begin(tm, app, StartTimeAt(startTime)).
+			Prepare(RecordEvent(EventA1))
+
+		return tm, startTime, &handledAt, tc
+	}
+
 	t.Run("it retains the virtual time between calls", func(t *testing.T) {
 		_, startTime, buf, tc := newFixture()
 
@@ -190,4 +253,212 @@ func TestAdvanceTime(t *testing.T) {
 			)
 		})
 	})
+
+	t.Run("passed a ToNextDeadline() adjustment", func(t *testing.T) {
+		t.Run("it advances the clock to the next pending deadline", func(t *testing.T) {
+			_, startTime, handledAt, tc := newDeadlineFixture()
+
+			tc.Prepare(AdvanceTime(ToNextDeadline()))
+
+			xtesting.Expect(
+				t,
+				"unexpected deadline handling times",
+				*handledAt,
+				[]time.Time{startTime.Add(1 * time.Hour)},
+			)
+
+			deadlines := tc.PendingDeadlines()
+			if len(deadlines) != 2 {
+				t.Fatalf("expected 2 pending deadlines, got %d", len(deadlines))
+			}
+
+			xtesting.Expect(t, "unexpected deadline", deadlines[0].Message, dogma.Deadline(DeadlineA3))
+			xtesting.Expect(t, "unexpected deadline", deadlines[1].Message, dogma.Deadline(DeadlineA2))
+		})
+
+		t.Run("it produces the expected caption", func(t *testing.T) {
+			tm, _, _, tc := newDeadlineFixture()
+
+			tc.Prepare(AdvanceTime(ToNextDeadline()))
+
+			xtesting.ExpectContains(
+				t,
+				"expected caption",
+				tm.Logs,
+				"--- advancing time to the next deadline ---",
+			)
+		})
+
+		t.Run("it fails the test if there are no pending deadlines", func(t *testing.T) {
+			tm, _, _, tc := newFixture()
+			tm.FailSilently = true
+
+			tc.Prepare(AdvanceTime(ToNextDeadline()))
+
+			if !tm.Failed() {
+				t.Fatal("expected test to fail")
+			}
+
+			xtesting.ExpectContains(
+				t,
+				"expected failure log",
+				tm.Logs,
+				"cannot adjust the clock to the next deadline, there are no pending deadlines",
+			)
+		})
+
+		t.Run("it ignores deadlines scheduled by disabled processes", func(t *testing.T) {
+			tm, _, handledAt, tc := newDeadlineFixture()
+			tm.FailSilently = true
+
+			tc.DisableHandlers("<process>").
+				Prepare(AdvanceTime(ToNextDeadline()))
+
+			if !tm.Failed() {
+				t.Fatal("expected test to fail")
+			}
+
+			xtesting.ExpectContains(
+				t,
+				"expected failure log",
+				tm.Logs,
+				"cannot adjust the clock to the next deadline, there are no pending deadlines",
+			)
+			xtesting.Expect(t, "unexpected number of handled deadlines", len(*handledAt), 0)
+			xtesting.Expect(t, "unexpected number of pending deadlines", len(tc.PendingDeadlines()), 0)
+		})
+
+		t.Run("it panics if Step() is called directly", func(t *testing.T) {
+			xtesting.ExpectPanic(
+				t,
+				"ToNextDeadline(): the adjustment depends on the engine's pending deadlines, it can only be used with AdvanceTime()",
+				func() {
+					ToNextDeadline().Step(time.Now())
+				},
+			)
+		})
+	})
+
+	t.Run("passed a ThroughAllDeadlines() adjustment", func(t *testing.T) {
+		t.Run("it advances the clock to the last pending deadline", func(t *testing.T) {
+			_, startTime, handledAt, tc := newDeadlineFixture()
+
+			tc.Prepare(AdvanceTime(ThroughAllDeadlines()))
+
+			xtesting.Expect(
+				t,
+				"unexpected deadline handling times",
+				*handledAt,
+				[]time.Time{
+					startTime.Add(2 * time.Hour),
+					startTime.Add(2 * time.Hour),
+				},
+			)
+
+			xtesting.Expect(t, "unexpected number of pending deadlines", len(tc.PendingDeadlines()), 1)
+		})
+
+		t.Run("it produces the expected caption", func(t *testing.T) {
+			tm, _, _, tc := newDeadlineFixture()
+
+			tc.Prepare(AdvanceTime(ThroughAllDeadlines()))
+
+			xtesting.ExpectContains(
+				t,
+				"expected caption",
+				tm.Logs,
+				"--- advancing time through all pending deadlines ---",
+			)
+		})
+
+		t.Run("it ignores deadlines scheduled by disabled processes", func(t *testing.T) {
+			tm, _, handledAt, tc := newDeadlineFixture()
+			tm.FailSilently = true
+
+			tc.DisableHandlers("<process>").
+				Prepare(AdvanceTime(ThroughAllDeadlines()))
+
+			if !tm.Failed() {
+				t.Fatal("expected test to fail")
+			}
+
+			xtesting.ExpectContains(
+				t,
+				"expected failure log",
+				tm.Logs,
+				"cannot adjust the clock through all pending deadlines, there are no pending deadlines",
+			)
+			xtesting.Expect(t, "unexpected number of handled deadlines", len(*handledAt), 0)
+		})
+
+		t.Run("it panics if Step() is called directly", func(t *testing.T) {
+			xtesting.ExpectPanic(
+				t,
+				"ThroughAllDeadlines(): the adjustment depends on the engine's pending deadlines, it can only be used with AdvanceTime()",
+				func() {
+					ThroughAllDeadlines().Step(time.Now())
+				},
+			)
+		})
+	})
+
+	t.Run("passed the StepThroughDeadlines() option", func(t *testing.T) {
+		t.Run("it handles each deadline at its scheduled time", func(t *testing.T) {
+			_, startTime, handledAt, tc := newDeadlineFixture()
+
+			tc.Prepare(
+				AdvanceTime(
+					ByDuration(3*time.Hour),
+					StepThroughDeadlines(),
+				),
+			)
+
+			xtesting.Expect(
+				t,
+				"unexpected deadline handling times",
+				*handledAt,
+				[]time.Time{
+					startTime.Add(1 * time.Hour),
+					startTime.Add(90 * time.Minute),
+					startTime.Add(2 * time.Hour),
+				},
+			)
+
+			xtesting.Expect(t, "unexpected number of pending deadlines", len(tc.PendingDeadlines()), 0)
+		})
+
+		t.Run("it produces the expected caption", func(t *testing.T) {
+			tm, _, _, tc := newDeadlineFixture()
+
+			tc.Prepare(
+				AdvanceTime(
+					ByDuration(3*time.Hour),
+					StepThroughDeadlines(),
+				),
+			)
+
+			xtesting.ExpectContains(
+				t,
+				"expected caption",
+				tm.Logs,
+				"--- advancing time by 3h0m0s, stepping through deadlines ---",
+			)
+		})
+	})
+
+	t.Run("it handles deadlines at the adjusted time by default", func(t *testing.T) {
+		_, startTime, handledAt, tc := newDeadlineFixture()
+
+		tc.Prepare(AdvanceTime(ByDuration(3 * time.Hour)))
+
+		xtesting.Expect(
+			t,
+			"unexpected deadline handling times",
+			*handledAt,
+			[]time.Time{
+				startTime.Add(3 * time.Hour),
+				startTime.Add(3 * time.Hour),
+			},
+		)
+	})
 }
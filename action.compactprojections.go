@@ -0,0 +1,106 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/location"
+)
+
+// CompactProjections returns an Action that compacts the named projections
+// immediately, at the time on the test's virtual clock.
+//
+// If no names are given, every projection in the application is compacted,
+// including those that are disabled.
+//
+// Projections are otherwise compacted in parallel with the handling of each
+// event, and when the virtual clock is advanced by at least an hour.
+//
+// A failure to compact a projection does not cause the test to fail. Instead,
+// use Test.Expect() with the ToCompactProjection(),
+// ToFailToCompactProjection() or ToLogDuringProjectionCompaction()
+// expectations to verify the outcome of the compaction.
+func CompactProjections(names ...string) Action {
+	return compactProjectionsAction{
+		names,
+		location.OfCall(),
+	}
+}
+
+// compactProjectionsAction is an implementation of Action that compacts
+// projections.
+type compactProjectionsAction struct {
+	names []string
+	loc   location.Location
+}
+
+func (a compactProjectionsAction) Caption() string {
+	switch len(a.names) {
+	case 0:
+		return "compacting all projections"
+	case 1:
+		return fmt.Sprintf("compacting the %q projection", a.names[0])
+	default:
+		quoted := make([]string, len(a.names))
+		for i, n := range a.names {
+			quoted[i] = fmt.Sprintf("%q", n)
+		}
+
+		return fmt.Sprintf(
+			"compacting the %s projections",
+			strings.Join(quoted, ", "),
+		)
+	}
+}
+
+func (a compactProjectionsAction) Location() location.Location {
+	return a.loc
+}
+
+func (a compactProjectionsAction) ConfigurePredicate(*PredicateOptions) {
+}
+
+func (a compactProjectionsAction) Do(ctx context.Context, s ActionScope) error {
+	names := a.names
+
+	if len(names) == 0 {
+		for _, h := range s.App.Handlers() {
+			if h.HandlerType() == config.ProjectionHandlerType {
+				names = append(names, h.Identity().GetName())
+			}
+		}
+	}
+
+	for _, n := range names {
+		h, ok := s.App.HandlerByName(n)
+		if !ok {
+			return fmt.Errorf(
+				"cannot compact projection, the %q application does not have a handler named %q",
+				s.App.Identity().GetName(),
+				n,
+			)
+		}
+
+		if h.HandlerType() != config.ProjectionHandlerType {
+			return fmt.Errorf(
+				"cannot compact projection, the %q handler is not a projection",
+				n,
+			)
+		}
+	}
+
+	for _, n := range names {
+		// The compaction error is deliberately ignored. It is reported via the
+		// fact.ProjectionCompactionCompleted fact, which is inspected by the
+		// compaction expectations.
+		_ = s.Engine.CompactProjection(ctx, n, s.OperationOptions...)
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
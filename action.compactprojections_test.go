@@ -0,0 +1,115 @@
+package testkit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestCompactProjections(t *testing.T) {
+	var compacted []string
+
+	newProjection := func(name, key string) dogma.ProjectionMessageHandler {
+		return &ProjectionMessageHandlerStub{
+			ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+				c.Identity(name, key)
+				c.Routes(
+					dogma.HandlesEvent[*EventStub[TypeA]](),
+				)
+			},
+			CompactFunc: func(context.Context, dogma.ProjectionCompactScope) error {
+				compacted = append(compacted, name)
+				return nil
+			},
+		}
+	}
+
+	app := &ApplicationStub{
+		ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+			c.Identity("<app>", "4f8a2c6e-1b3d-4e5f-9a7c-2d4b6e8f0a1c")
+			c.Routes(
+				dogma.ViaProjection(newProjection("<projection-1>", "8c1e3a5f-7b9d-4f2a-b4c6-e8d0a2c4f6b8")),
+				dogma.ViaProjection(newProjection("<projection-2>", "2a4c6e8f-0b1d-4a3c-8e5f-7b9d1f3a5c7e")),
+				dogma.ViaIntegration(&IntegrationMessageHandlerStub{
+					ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+						c.Identity("<integration>", "9e7c5a3f-1d2b-4c6e-a8f0-b2d4f6a8c0e1")
+						c.Routes(
+							dogma.HandlesCommand[*CommandStub[TypeA]](),
+						)
+					},
+				}),
+			)
+		},
+	}
+
+	t.Run("it compacts the named projections", func(t *testing.T) {
+		compacted = nil
+
+		Begin(t, app).
+			Prepare(CompactProjections("<projection-2>"))
+
+		xtesting.Expect(
+			t,
+			"unexpected compacted projections",
+			compacted,
+			[]string{"<projection-2>"},
+		)
+	})
+
+	t.Run("it compacts all projections if no names are given", func(t *testing.T) {
+		compacted = nil
+
+		Begin(t, app).
+			Prepare(CompactProjections())
+
+		xtesting.Expect(
+			t,
+			"unexpected compacted projections",
+			compacted,
+			[]string{"<projection-1>", "<projection-2>"},
+		)
+	})
+
+	t.Run("it fails the test if a handler is not recognized", func(t *testing.T) {
+		compacted = nil
+		tm := &testingmock.T{FailSilently: true}
+
+		Begin(tm, app).
+			Prepare(CompactProjections("<projection-1>", "<unknown>"))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot compact projection, the "<app>" application does not have a handler named "<unknown>"`,
+		)
+		xtesting.Expect(t, "unexpected compacted projections", len(compacted), 0)
+	})
+
+	t.Run("it fails the test if a handler is not a projection", func(t *testing.T) {
+		compacted = nil
+		tm := &testingmock.T{FailSilently: true}
+
+		Begin(tm, app).
+			Prepare(CompactProjections("<projection-1>", "<integration>"))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot compact projection, the "<integration>" handler is not a projection`,
+		)
+		xtesting.Expect(t, "unexpected compacted projections", len(compacted), 0)
+	})
+}
@@ -0,0 +1,106 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/internal/validation"
+	"github.com/dogmatiq/testkit/location"
+)
+
+// DeliverDeadline returns an Action that delivers a deadline message directly
+// to a process instance, at the time on the test's virtual clock.
+//
+// This allows a process's handling of a deadline to be tested on its own,
+// without first causing the instance to schedule the deadline and then
+// advancing the virtual clock.
+//
+// The deadline is routed in the same way as a scheduled deadline. If the
+// instance has not begun, or has ended, the deadline is ignored. See
+// engine.Engine.DeliverDeadline().
+func DeliverDeadline(handler, instanceID string, m dogma.Deadline) Action {
+	if m == nil {
+		panic("DeliverDeadline(<nil>): message must not be nil")
+	}
+
+	mt := message.TypeOf(m)
+
+	if err := m.Validate(validation.DeadlineValidationScope()); err != nil {
+		panic(fmt.Sprintf("DeliverDeadline(%s): %s", mt, err))
+	}
+
+	if instanceID == "" {
+		panic(fmt.Sprintf("DeliverDeadline(%s): instance ID must not be empty", mt))
+	}
+
+	return deliverDeadlineAction{
+		handler,
+		instanceID,
+		m,
+		location.OfCall(),
+	}
+}
+
+// deliverDeadlineAction is an implementation of Action that delivers a
+// deadline message to a process instance.
+type deliverDeadlineAction struct {
+	handler    string
+	instanceID string
+	m          dogma.Deadline
+	loc        location.Location
+}
+
+func (a deliverDeadlineAction) Caption() string {
+	return fmt.Sprintf(
+		"delivering %s deadline to the %q instance of the %q process",
+		message.TypeOf(a.m),
+		a.instanceID,
+		a.handler,
+	)
+}
+
+func (a deliverDeadlineAction) Location() location.Location {
+	return a.loc
+}
+
+func (a deliverDeadlineAction) ConfigurePredicate(*PredicateOptions) {
+}
+
+func (a deliverDeadlineAction) Do(ctx context.Context, s ActionScope) error {
+	mt := message.TypeOf(a.m)
+
+	h, ok := s.App.HandlerByName(a.handler)
+	if !ok {
+		return fmt.Errorf(
+			"cannot deliver deadline, the %q application does not have a handler named %q",
+			s.App.Identity().GetName(),
+			a.handler,
+		)
+	}
+
+	if h.HandlerType() != config.ProcessHandlerType {
+		return fmt.Errorf(
+			"cannot deliver deadline, the %q handler is not a process",
+			a.handler,
+		)
+	}
+
+	if !h.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
+		return fmt.Errorf(
+			"cannot deliver deadline, the %q process does not schedule %s deadlines",
+			a.handler,
+			mt,
+		)
+	}
+
+	return s.Engine.DeliverDeadline(
+		ctx,
+		a.handler,
+		a.instanceID,
+		a.m,
+		s.OperationOptions...,
+	)
+}
@@ -0,0 +1,224 @@
+package testkit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestDeliverDeadline(t *testing.T) {
+	newFixture := func(end bool) (*testingmock.T, time.Time, *[]time.Time, *Test) {
+		startTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+		var handledAt []time.Time
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "b0f5c8e2-3a4d-4c1b-9e7f-6d2a8b1c5e3f")
+				c.Routes(
+					dogma.ViaProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
+						ConfigureFunc: func(c dogma.ProcessConfigurer) {
+							c.Identity("<process>", "4e8d2c6a-1b3f-4a7e-8c5d-9f2e1a6b3c7d")
+							c.Routes(
+								dogma.HandlesEvent[*EventStub[TypeA]](),
+								dogma.ExecutesCommand[*CommandStub[TypeA]](),
+								dogma.SchedulesDeadline[*DeadlineStub[TypeA]](),
+							)
+						},
+						RouteEventToInstanceFunc: func(context.Context, dogma.Event) (string, bool, error) {
+							return "<instance>", true, nil
+						},
+						HandleEventFunc: func(
+							_ context.Context,
+							_ *ProcessRootStub,
+							s dogma.ProcessEventScope[*ProcessRootStub],
+							_ dogma.Event,
+						) error {
+							if end {
+								s.End()
+							}
+							return nil
+						},
+						HandleDeadlineFunc: func(
+							_ context.Context,
+							_ *ProcessRootStub,
+							s dogma.ProcessDeadlineScope[*ProcessRootStub],
+							_ dogma.Deadline,
+						) error {
+							handledAt = append(handledAt, s.Now())
+							s.ExecuteCommand(CommandA1)
+							return nil
+						},
+					}),
+					dogma.ViaAggregate(&AggregateMessageHandlerStub[*AggregateRootStub]{
+						ConfigureFunc: func(c dogma.AggregateConfigurer) {
+							c.Identity("<aggregate>", "7a2c4e6b-8d1f-4b3a-9c5e-2f6d8a1b4c7e")
+							c.Routes(
+								dogma.HandlesCommand[*CommandStub[TypeB]](),
+								dogma.RecordsEvent[*EventStub[TypeB]](),
+							)
+						},
+						RouteCommandToInstanceFunc: func(dogma.Command) string {
+							return "<instance>"
+						},
+					}),
+				)
+			},
+		}
+
+		tm := &testingmock.T{}
+		tc := Begin(tm, app, StartTimeAt(startTime)).
+			Prepare(RecordEvent(EventA1))
+
+		return tm, startTime, &handledAt, tc
+	}
+
+	t.Run("it delivers the deadline to the process instance", func(t *testing.T) {
+		_, startTime, handledAt, tc := newFixture(false)
+
+		tc.Expect(
+			DeliverDeadline("<process>", "<instance>", DeadlineA1),
+			ToExecuteCommand(CommandA1),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected deadline handling times",
+			*handledAt,
+			[]time.Time{startTime},
+		)
+	})
+
+	t.Run("it delivers the deadline at the time on the virtual clock", func(t *testing.T) {
+		_, startTime, handledAt, tc := newFixture(false)
+
+		tc.Prepare(
+			AdvanceTime(ByDuration(time.Hour)),
+			DeliverDeadline("<process>", "<instance>", DeadlineA1),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected deadline handling times",
+			*handledAt,
+			[]time.Time{startTime.Add(time.Hour)},
+		)
+	})
+
+	t.Run("it ignores the deadline if the instance has ended", func(t *testing.T) {
+		tm, _, handledAt, tc := newFixture(true)
+
+		tc.Prepare(
+			DeliverDeadline("<process>", "<instance>", DeadlineA1),
+		)
+
+		xtesting.Expect(t, "unexpected number of deliveries", len(*handledAt), 0)
+		xtesting.ExpectContains(
+			t,
+			"expected log message",
+			tm.Logs,
+			"= 02  ∵ 02  ⋲ 02  ▼ ≡    <process> <instance> ● deadline ignored because the target instance has ended",
+		)
+	})
+
+	t.Run("it fails the test if the handler is not recognized", func(t *testing.T) {
+		tm, _, _, tc := newFixture(false)
+		tm.FailSilently = true
+
+		tc.Prepare(DeliverDeadline("<unknown>", "<instance>", DeadlineA1))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot deliver deadline, the "<app>" application does not have a handler named "<unknown>"`,
+		)
+	})
+
+	t.Run("it fails the test if the handler is not a process", func(t *testing.T) {
+		tm, _, _, tc := newFixture(false)
+		tm.FailSilently = true
+
+		tc.Prepare(DeliverDeadline("<aggregate>", "<instance>", DeadlineA1))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot deliver deadline, the "<aggregate>" handler is not a process`,
+		)
+	})
+
+	t.Run("it fails the test if the process does not schedule the deadline type", func(t *testing.T) {
+		tm, _, _, tc := newFixture(false)
+		tm.FailSilently = true
+
+		tc.Prepare(DeliverDeadline("<process>", "<instance>", DeadlineB1))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot deliver deadline, the "<process>" process does not schedule *stubs.DeadlineStub[TypeB] deadlines`,
+		)
+	})
+
+	t.Run("it produces the expected caption", func(t *testing.T) {
+		tm, _, _, tc := newFixture(false)
+
+		tc.Prepare(DeliverDeadline("<process>", "<instance>", DeadlineA1))
+
+		xtesting.ExpectContains(
+			t,
+			"expected caption",
+			tm.Logs,
+			`--- delivering *stubs.DeadlineStub[TypeA] deadline to the "<instance>" instance of the "<process>" process ---`,
+		)
+	})
+
+	t.Run("it panics if the message is nil", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"DeliverDeadline(<nil>): message must not be nil",
+			func() {
+				DeliverDeadline("<process>", "<instance>", nil)
+			},
+		)
+	})
+
+	t.Run("it panics if the instance ID is empty", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"DeliverDeadline(*stubs.DeadlineStub[TypeA]): instance ID must not be empty",
+			func() {
+				DeliverDeadline("<process>", "", DeadlineA1)
+			},
+		)
+	})
+
+	t.Run("it captures the location that the action was created", func(t *testing.T) {
+		act := deliverDeadline("<process>", "<instance>", DeadlineA1)
+		loc := act.Location()
+
+		xtesting.Expect(t, "unexpected function name", loc.Func, "github.com/dogmatiq/testkit_test.deliverDeadline")
+		if !strings.HasSuffix(loc.File, "/action.linenumber_test.go") {
+			t.Fatalf("unexpected file: %s", loc.File)
+		}
+		xtesting.Expect(t, "unexpected line", loc.Line, 55)
+	})
+}
@@ -0,0 +1,130 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/internal/validation"
+	"github.com/dogmatiq/testkit/location"
+)
+
+// GivenAggregateEvents returns an Action that adds events to the history of an
+// aggregate instance, as though the instance had recorded them at the time on
+// the test's virtual clock.
+//
+// The events are applied to the instance's root, but no commands are handled
+// and the events are not dispatched to any other handlers. This allows tests to
+// place an aggregate instance into a particular state without executing the
+// commands that would otherwise cause it to record the events.
+//
+// See engine.Engine.SeedAggregateEvents().
+func GivenAggregateEvents(handler, instanceID string, events ...dogma.Event) Action {
+	if len(events) == 0 {
+		panic(fmt.Sprintf(
+			"GivenAggregateEvents(%q, %q): at least one event must be provided",
+			handler,
+			instanceID,
+		))
+	}
+
+	if instanceID == "" {
+		panic(fmt.Sprintf(
+			"GivenAggregateEvents(%q, %q): instance ID must not be empty",
+			handler,
+			instanceID,
+		))
+	}
+
+	for _, m := range events {
+		if m == nil {
+			panic(fmt.Sprintf(
+				"GivenAggregateEvents(%q, %q): events must not be nil",
+				handler,
+				instanceID,
+			))
+		}
+
+		if err := m.Validate(validation.EventValidationScope()); err != nil {
+			panic(fmt.Sprintf("GivenAggregateEvents(%s): %s", message.TypeOf(m), err))
+		}
+	}
+
+	return givenAggregateEventsAction{
+		handler,
+		instanceID,
+		events,
+		location.OfCall(),
+	}
+}
+
+// givenAggregateEventsAction is an implementation of Action that adds events
+// to the history of an aggregate instance.
+type givenAggregateEventsAction struct {
+	handler    string
+	instanceID string
+	events     []dogma.Event
+	loc        location.Location
+}
+
+func (a givenAggregateEventsAction) Caption() string {
+	noun := "events"
+	if len(a.events) == 1 {
+		noun = "event"
+	}
+
+	return fmt.Sprintf(
+		"seeding the %q instance of the %q aggregate with %d %s",
+		a.instanceID,
+		a.handler,
+		len(a.events),
+		noun,
+	)
+}
+
+func (a givenAggregateEventsAction) Location() location.Location {
+	return a.loc
+}
+
+func (a givenAggregateEventsAction) ConfigurePredicate(*PredicateOptions) {
+}
+
+func (a givenAggregateEventsAction) Do(ctx context.Context, s ActionScope) error {
+	h, ok := s.App.HandlerByName(a.handler)
+	if !ok {
+		return fmt.Errorf(
+			"cannot seed events, the %q application does not have a handler named %q",
+			s.App.Identity().GetName(),
+			a.handler,
+		)
+	}
+
+	if h.HandlerType() != config.AggregateHandlerType {
+		return fmt.Errorf(
+			"cannot seed events, the %q handler is not an aggregate",
+			a.handler,
+		)
+	}
+
+	for _, m := range a.events {
+		mt := message.TypeOf(m)
+
+		if !h.RouteSet().DirectionOf(mt).Has(config.OutboundDirection) {
+			return fmt.Errorf(
+				"cannot seed events, the %q aggregate does not record %s events",
+				a.handler,
+				mt,
+			)
+		}
+	}
+
+	return s.Engine.SeedAggregateEvents(
+		ctx,
+		a.handler,
+		a.instanceID,
+		a.events,
+		s.OperationOptions...,
+	)
+}
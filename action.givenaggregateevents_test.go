@@ -0,0 +1,235 @@
+package testkit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestGivenAggregateEvents(t *testing.T) {
+	newFixture := func() (*testingmock.T, *int, *int, *Test) {
+		var commands, events int
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "0d6b3f1e-8a2c-4e7d-9b5f-3c1a7e2d6f4b")
+				c.Routes(
+					dogma.ViaAggregate(&AggregateMessageHandlerStub[*AggregateRootStub]{
+						ConfigureFunc: func(c dogma.AggregateConfigurer) {
+							c.Identity("<aggregate>", "5c2e7a1d-3f8b-4d6e-a9c4-1b7f3e5d2a8c")
+							c.Routes(
+								dogma.HandlesCommand[*CommandStub[TypeA]](),
+								dogma.RecordsEvent[*EventStub[TypeA]](),
+							)
+						},
+						RouteCommandToInstanceFunc: func(dogma.Command) string {
+							return "<instance>"
+						},
+						HandleCommandFunc: func(
+							r *AggregateRootStub,
+							s dogma.AggregateCommandScope[*AggregateRootStub],
+							_ dogma.Command,
+						) {
+							commands++
+
+							if len(r.AppliedEvents) == 0 {
+								s.RecordEvent(EventA1)
+							} else {
+								s.RecordEvent(EventA2)
+							}
+						},
+					}),
+					dogma.ViaProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
+						ConfigureFunc: func(c dogma.ProcessConfigurer) {
+							c.Identity("<process>", "9e4a2c7f-1d5b-4b8e-8f3a-6c2d9e1b7a5f")
+							c.Routes(
+								dogma.HandlesEvent[*EventStub[TypeA]](),
+								dogma.ExecutesCommand[*CommandStub[TypeB]](),
+							)
+						},
+						RouteEventToInstanceFunc: func(context.Context, dogma.Event) (string, bool, error) {
+							return "<instance>", true, nil
+						},
+						HandleEventFunc: func(
+							context.Context,
+							*ProcessRootStub,
+							dogma.ProcessEventScope[*ProcessRootStub],
+							dogma.Event,
+						) error {
+							events++
+							return nil
+						},
+					}),
+				)
+			},
+		}
+
+		tm := &testingmock.T{}
+		return tm, &commands, &events, Begin(tm, app)
+	}
+
+	t.Run("it applies the events to the aggregate root", func(t *testing.T) {
+		_, _, _, tc := newFixture()
+
+		tc.Prepare(
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA1, EventA3),
+		)
+
+		r, ok := tc.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{EventA1, EventA3},
+			}),
+		)
+	})
+
+	t.Run("it logs the seeded events", func(t *testing.T) {
+		tm, _, _, tc := newFixture()
+
+		tc.Prepare(GivenAggregateEvents("<aggregate>", "<instance>", EventA1))
+
+		xtesting.ExpectContains(
+			t,
+			"expected fact to be logged",
+			tm.Logs,
+			"= 01  ∵ 01  ⋲ 01  ▲ ∴    <aggregate> <instance> ● seeded an event ● *stubs.EventStub[TypeA]! ● event(stubs.TypeA:A1, valid)",
+		)
+	})
+
+	t.Run("it does not handle any messages", func(t *testing.T) {
+		_, commands, events, tc := newFixture()
+
+		tc.Prepare(
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA1),
+		)
+
+		xtesting.Expect(t, "unexpected number of commands handled", *commands, 0)
+		xtesting.Expect(t, "unexpected number of events handled", *events, 0)
+	})
+
+	t.Run("it affects the handling of subsequent commands", func(t *testing.T) {
+		_, _, _, tc := newFixture()
+
+		tc.Prepare(
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA1),
+		).Expect(
+			ExecuteCommand(CommandA1),
+			ToRecordEvent(EventA2),
+		)
+	})
+
+	t.Run("it does not satisfy expectations", func(t *testing.T) {
+		tm, _, _, tc := newFixture()
+		tm.FailSilently = true
+
+		tc.Expect(
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA1),
+			ToRecordEvent(EventA1),
+		)
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+	})
+
+	t.Run("it fails the test if the handler is not an aggregate", func(t *testing.T) {
+		tm, _, _, tc := newFixture()
+		tm.FailSilently = true
+
+		tc.Prepare(GivenAggregateEvents("<process>", "<instance>", EventA1))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot seed events, the "<process>" handler is not an aggregate`,
+		)
+	})
+
+	t.Run("it fails the test if the aggregate does not record the event type", func(t *testing.T) {
+		tm, _, _, tc := newFixture()
+		tm.FailSilently = true
+
+		tc.Prepare(GivenAggregateEvents("<aggregate>", "<instance>", EventA1, EventB1))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot seed events, the "<aggregate>" aggregate does not record *stubs.EventStub[TypeB] events`,
+		)
+	})
+
+	t.Run("it produces the expected caption", func(t *testing.T) {
+		tm, _, _, tc := newFixture()
+
+		tc.Prepare(
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA1),
+			GivenAggregateEvents("<aggregate>", "<instance>", EventA2, EventA3),
+		)
+
+		xtesting.ExpectContains(
+			t,
+			"expected caption",
+			tm.Logs,
+			`--- seeding the "<instance>" instance of the "<aggregate>" aggregate with 1 event ---`,
+		)
+
+		xtesting.ExpectContains(
+			t,
+			"expected caption",
+			tm.Logs,
+			`--- seeding the "<instance>" instance of the "<aggregate>" aggregate with 2 events ---`,
+		)
+	})
+
+	t.Run("it panics if no events are provided", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			`GivenAggregateEvents("<aggregate>", "<instance>"): at least one event must be provided`,
+			func() {
+				GivenAggregateEvents("<aggregate>", "<instance>")
+			},
+		)
+	})
+
+	t.Run("it panics if any of the events are nil", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			`GivenAggregateEvents("<aggregate>", "<instance>"): events must not be nil`,
+			func() {
+				GivenAggregateEvents("<aggregate>", "<instance>", EventA1, nil)
+			},
+		)
+	})
+
+	t.Run("it captures the location that the action was created", func(t *testing.T) {
+		act := givenAggregateEvents("<aggregate>", "<instance>", EventA1)
+		loc := act.Location()
+
+		xtesting.Expect(t, "unexpected function name", loc.Func, "github.com/dogmatiq/testkit_test.givenAggregateEvents")
+		if !strings.HasSuffix(loc.File, "/action.linenumber_test.go") {
+			t.Fatalf("unexpected file: %s", loc.File)
+		}
+		xtesting.Expect(t, "unexpected line", loc.Line, 58)
+	})
+}
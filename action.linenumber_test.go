@@ -51,3 +51,9 @@ func advanceTime(adj TimeAdjustment) Action { return AdvanceTime(adj) }
 func call(fn func()) Action                 { return Call(fn) }
 func executeCommand(m dogma.Command) Action { return ExecuteCommand(m) }
 func recordEvent(m dogma.Event) Action      { return RecordEvent(m) }
+
+func deliverDeadline(h, id string, m dogma.Deadline) Action { return DeliverDeadline(h, id, m) }
+
+func givenAggregateEvents(h, id string, m ...dogma.Event) Action {
+	return GivenAggregateEvents(h, id, m...)
+}
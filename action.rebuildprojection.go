@@ -0,0 +1,65 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/location"
+)
+
+// RebuildProjection returns an Action that resets the named projection and
+// redelivers every event that it consumes, in the order they were originally
+// recorded.
+//
+// This allows testing that a projection produces the same data when it is
+// rebuilt from the event history as it does when events are handled as they
+// occur. It also allows a projection that is enabled part-way through a test
+// to catch up with the events that it has missed.
+//
+// The events are redelivered even if the projection is disabled. See
+// engine.Engine.RebuildProjection().
+func RebuildProjection(name string) Action {
+	return rebuildProjectionAction{
+		name,
+		location.OfCall(),
+	}
+}
+
+// rebuildProjectionAction is an implementation of Action that rebuilds a
+// projection from the event history.
+type rebuildProjectionAction struct {
+	name string
+	loc  location.Location
+}
+
+func (a rebuildProjectionAction) Caption() string {
+	return fmt.Sprintf("rebuilding the %q projection", a.name)
+}
+
+func (a rebuildProjectionAction) Location() location.Location {
+	return a.loc
+}
+
+func (a rebuildProjectionAction) ConfigurePredicate(*PredicateOptions) {
+}
+
+func (a rebuildProjectionAction) Do(ctx context.Context, s ActionScope) error {
+	h, ok := s.App.HandlerByName(a.name)
+	if !ok {
+		return fmt.Errorf(
+			"cannot rebuild projection, the %q application does not have a handler named %q",
+			s.App.Identity().GetName(),
+			a.name,
+		)
+	}
+
+	if h.HandlerType() != config.ProjectionHandlerType {
+		return fmt.Errorf(
+			"cannot rebuild projection, the %q handler is not a projection",
+			a.name,
+		)
+	}
+
+	return s.Engine.RebuildProjection(ctx, a.name, s.OperationOptions...)
+}
@@ -0,0 +1,125 @@
+package testkit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestRebuildProjection(t *testing.T) {
+	var events []dogma.Event
+
+	projection := &ProjectionMessageHandlerStub{
+		ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+			c.Identity("<projection>", "2f7c9a1e-4b8d-4c3a-9e6f-1d5b8a2c7e4f")
+			c.Routes(
+				dogma.HandlesEvent[*EventStub[TypeA]](),
+			)
+		},
+		HandleEventFunc: func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			e dogma.Event,
+		) (uint64, error) {
+			events = append(events, e)
+			return s.Offset() + 1, nil
+		},
+		ResetFunc: func(context.Context, dogma.ProjectionResetScope) error {
+			events = nil
+			return nil
+		},
+	}
+
+	app := &ApplicationStub{
+		ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+			c.Identity("<app>", "6c3e8b2a-9d1f-4a7e-b5c4-3f2a1e9d8b7c")
+			c.Routes(
+				dogma.ViaProjection(projection),
+				dogma.ViaIntegration(&IntegrationMessageHandlerStub{
+					ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+						c.Identity("<integration>", "0b3d5f7a-1c2e-4d6f-8a9b-7e5c3a1d2f4b")
+						c.Routes(
+							dogma.HandlesCommand[*CommandStub[TypeA]](),
+						)
+					},
+				}),
+			)
+		},
+	}
+
+	t.Run("it delivers historical events to the projection", func(t *testing.T) {
+		events = nil
+
+		Begin(t, app).
+			Prepare(
+				RecordEvent(EventA1),
+				RecordEvent(EventA2),
+				RebuildProjection("<projection>"),
+			)
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			events,
+			[]dogma.Event{EventA1, EventA2},
+		)
+	})
+
+	t.Run("it replaces the data produced while handling events as they occur", func(t *testing.T) {
+		events = nil
+
+		Begin(t, app).
+			EnableHandlers("<projection>").
+			Prepare(
+				RecordEvent(EventA1),
+				RecordEvent(EventA2),
+				RebuildProjection("<projection>"),
+			)
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			events,
+			[]dogma.Event{EventA1, EventA2},
+		)
+	})
+
+	t.Run("it fails the test if the handler is not recognized", func(t *testing.T) {
+		tm := &testingmock.T{FailSilently: true}
+
+		Begin(tm, app).
+			Prepare(RebuildProjection("<unknown>"))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot rebuild projection, the "<app>" application does not have a handler named "<unknown>"`,
+		)
+	})
+
+	t.Run("it fails the test if the handler is not a projection", func(t *testing.T) {
+		tm := &testingmock.T{FailSilently: true}
+
+		Begin(tm, app).
+			Prepare(RebuildProjection("<integration>"))
+
+		if !tm.Failed() {
+			t.Fatal("expected test to fail")
+		}
+		xtesting.ExpectContains(
+			t,
+			"expected error log",
+			tm.Logs,
+			`cannot rebuild projection, the "<integration>" handler is not a projection`,
+		)
+	})
+}
@@ -0,0 +1,44 @@
+package testkit
+
+import (
+	"context"
+
+	"github.com/dogmatiq/testkit/location"
+)
+
+// RetryDeadLetters returns an Action that redelivers each dead-lettered message
+// to the handler that failed to handle it.
+//
+// It is intended to be used after the test has fixed the condition that caused
+// the handler to fail, mirroring the way an operator recovers from failures in
+// production. Use Test.DeadLetters() to inspect the messages that have been
+// dead-lettered.
+//
+// Messages are only dead-lettered without failing the test if the engine has a
+// retry policy; see engine.WithRetryPolicy() and WithUnsafeEngineOptions().
+func RetryDeadLetters() Action {
+	return retryDeadLettersAction{
+		location.OfCall(),
+	}
+}
+
+// retryDeadLettersAction is an implementation of Action that redelivers
+// dead-lettered messages.
+type retryDeadLettersAction struct {
+	loc location.Location
+}
+
+func (a retryDeadLettersAction) Caption() string {
+	return "retrying dead-lettered messages"
+}
+
+func (a retryDeadLettersAction) Location() location.Location {
+	return a.loc
+}
+
+func (a retryDeadLettersAction) ConfigurePredicate(*PredicateOptions) {
+}
+
+func (a retryDeadLettersAction) Do(ctx context.Context, s ActionScope) error {
+	return s.Engine.RetryDeadLetters(ctx, s.OperationOptions...)
+}
@@ -0,0 +1,74 @@
+package testkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestRetryDeadLetters(t *testing.T) {
+	fail := true
+
+	handler := &IntegrationMessageHandlerStub{
+		ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+			c.Identity("<integration>", "e6a4b1c2-7d3f-4e8a-9b5c-2f1d0a6e8b7c")
+			c.Routes(
+				dogma.HandlesCommand[*CommandStub[TypeA]](),
+				dogma.RecordsEvent[*EventStub[TypeA]](),
+			)
+		},
+		HandleCommandFunc: func(
+			_ context.Context,
+			s dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			if fail {
+				return errors.New("<error>")
+			}
+
+			s.RecordEvent(EventA1)
+			return nil
+		},
+	}
+
+	app := &ApplicationStub{
+		ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+			c.Identity("<app>", "b9d2e7f1-3c4a-4b6e-8d0f-5a1c7e9b2d4f")
+			c.Routes(
+				dogma.ViaIntegration(handler),
+			)
+		},
+	}
+
+	test := Begin(
+		t,
+		app,
+		WithUnsafeEngineOptions(
+			engine.WithRetryPolicy(engine.RetryPolicy{MaxAttempts: 1}),
+		),
+	).
+		EnableHandlers("<integration>").
+		Prepare(ExecuteCommand(CommandA1))
+
+	letters := test.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead-letter, got %d", len(letters))
+	}
+
+	xtesting.Expect(t, "unexpected message", letters[0].Envelope.Message, dogma.Message(CommandA1))
+
+	fail = false
+
+	test.Expect(
+		RetryDeadLetters(),
+		ToRecordEvent(EventA1),
+	)
+
+	xtesting.Expect(t, "unexpected dead-letters", len(test.DeadLetters()), 0)
+}
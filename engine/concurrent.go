@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/engine/internal/routing"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+	"go.uber.org/multierr"
+)
+
+// WithConcurrentDispatch returns an operation option that handles independent
+// messages on separate goroutines.
+//
+// Messages are dispatched in "waves", each consisting of the messages that are
+// queued for dispatch when the wave begins. Within a wave, messages for
+// different aggregate or process instances, and for different integrations or
+// projections, are handled concurrently. Messages for the same instance, or the
+// same integration or projection, are handled one at a time, in the order they
+// were queued. This allows the race detector to find data races in handlers
+// that share state between instances.
+//
+// The facts produced while handling each message are buffered and passed to
+// the observers in the same order as they would be if the messages were
+// handled one at a time. The assignment of message IDs and the invocation
+// counts used by FailHandler() and PanicHandler() still depend on the order in
+// which the handlers are actually called.
+//
+// If a handler panics, the panic is re-raised on the calling goroutine once
+// the wave is complete. The panic value is wrapped in a value that also
+// carries the stack trace of the goroutine on which the handler panicked.
+//
+// By default, messages are handled one at a time.
+func WithConcurrentDispatch() OperationOption {
+	return operationOptionFunc(func(_ *Engine, oo *operationOptions) {
+		oo.concurrent = true
+	})
+}
+
+// instanceRouter is an interface for controllers that manage handlers with
+// multiple instances.
+type instanceRouter interface {
+	// Route returns the result of routing env to an instance.
+	Route(ctx context.Context, env *envelope.Envelope) routing.Result
+
+	// HandleRoute handles env, which has already been routed to an instance by
+	// Route(), without routing it again.
+	HandleRoute(
+		ctx context.Context,
+		obs fact.Observer,
+		now time.Time,
+		env *envelope.Envelope,
+		r routing.Result,
+	) ([]*envelope.Envelope, error)
+}
+
+// instanceRoute is the result of routing a specific envelope to an instance.
+type instanceRoute struct {
+	env    *envelope.Envelope
+	result routing.Result
+}
+
+// task is the handling of a single message by a single handler during a
+// concurrent dispatch.
+type task struct {
+	env   *envelope.Envelope
+	c     controller
+	route *instanceRoute
+
+	facts    fact.Buffer
+	deferred []func()
+	envs     []*envelope.Envelope
+	err      error
+
+	panicked   bool
+	panicValue panicx.Recovered
+}
+
+// run handles the message, buffering the facts it produces and deferring any
+// changes it makes to the engine's state.
+func (t *task) run(
+	ctx context.Context,
+	e *Engine,
+	oo *operationOptions,
+) {
+	defer func() {
+		if v := recover(); v != nil {
+			t.panicked = true
+			t.panicValue = panicx.Recover(v)
+		}
+	}()
+
+	too := *oo
+	too.observers = fact.ObserverGroup{&t.facts}
+	too.deferred = &t.deferred
+
+	t.envs, t.err = e.handle(ctx, &too, t.env, t.c, t.route)
+}
+
+// dispatchConcurrently is a variant of dispatch() that handles the messages in
+// each wave concurrently, as per the WithConcurrentDispatch() option.
+func (e *Engine) dispatchConcurrently(
+	ctx context.Context,
+	oo *operationOptions,
+	queue []*envelope.Envelope,
+) error {
+	var err error
+
+	for len(queue) > 0 {
+		var (
+			wave   []*envelope.Envelope
+			tasks  [][]*task
+			groups = map[partitionKey][]*task{}
+			keys   []partitionKey
+		)
+
+		for len(queue) > 0 {
+			i := oo.order.next(queue)
+			env := queue[i]
+			queue = slices.Delete(queue, i, i+1)
+
+			mt := message.TypeOf(env.Message)
+			e.history.add(env)
+
+			if mt.Kind() == message.EventKind {
+				e.events.append(env)
+			}
+
+			var controllers []controller
+
+			if mt.Kind() == message.DeadlineKind {
+				// always dispatch deadline messages back to their origin handler
+				controllers = []controller{
+					e.controllers[env.Origin.Handler.Identity().GetName()],
+				}
+			} else {
+				controllers = oo.order.shuffle(e.routes[mt])
+			}
+
+			var envTasks []*task
+			for _, c := range controllers {
+				k, r := e.partition(ctx, oo, env, c)
+
+				t := &task{env: env, c: c, route: r}
+				envTasks = append(envTasks, t)
+
+				if _, ok := groups[k]; !ok {
+					keys = append(keys, k)
+				}
+				groups[k] = append(groups[k], t)
+			}
+
+			wave = append(wave, env)
+			tasks = append(tasks, envTasks)
+		}
+
+		var g sync.WaitGroup
+
+		for _, k := range keys {
+			g.Go(func() {
+				for _, t := range groups[k] {
+					t.run(ctx, e, oo)
+
+					if t.panicked {
+						return
+					}
+				}
+			})
+		}
+
+		g.Wait()
+
+		for i, env := range wave {
+			oo.observers.Notify(
+				fact.DispatchBegun{
+					Envelope: env,
+				},
+			)
+
+			var derr error
+			for _, t := range tasks[i] {
+				for _, f := range t.facts.Facts() {
+					oo.observers.Notify(f)
+				}
+
+				if t.panicked {
+					panic(t.panicValue)
+				}
+
+				for _, fn := range t.deferred {
+					fn()
+				}
+
+				queue = append(queue, t.envs...)
+
+				if t.err != nil {
+					derr = multierr.Append(
+						derr,
+						fmt.Errorf(
+							"%s %s: %w",
+							t.c.HandlerConfig().Identity().GetName(),
+							t.c.HandlerConfig().HandlerType(),
+							t.err,
+						),
+					)
+				}
+			}
+
+			oo.observers.Notify(
+				fact.DispatchCompleted{
+					Envelope: env,
+					Error:    derr,
+				},
+			)
+
+			err = multierr.Append(err, derr)
+		}
+
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+	}
+
+	return err
+}
+
+// partitionKey identifies a set of messages that must be handled one at a
+// time, in the order they were queued.
+type partitionKey struct {
+	handler  string
+	instance string
+}
+
+// partition returns the key of the partition that env belongs to when it is
+// handled by c.
+//
+// If c manages multiple instances, it also returns the result of routing env,
+// which is passed through to c so that the message is only routed once.
+func (e *Engine) partition(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+) (partitionKey, *instanceRoute) {
+	h := c.HandlerConfig()
+	k := partitionKey{handler: h.Identity().GetName()}
+
+	if skip, _ := e.skipHandler(h, oo); skip {
+		return k, nil
+	}
+
+	ir, ok := c.(instanceRouter)
+	if !ok {
+		return k, nil
+	}
+
+	r := &instanceRoute{env, ir.Route(ctx, env)}
+	if r.result.OK {
+		k.instance = r.result.InstanceID
+	}
+
+	return k, r
+}
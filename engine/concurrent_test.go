@@ -0,0 +1,270 @@
+package engine_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestWithConcurrentDispatch(t *testing.T) {
+	// newFixture returns an engine fixture in which the aggregate records
+	// events with the given content, and the process routes each event to the
+	// instance named by its content.
+	newFixture := func(content ...TypeA) *engineFixture {
+		fx := newEngineFixture()
+
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			for _, c := range content {
+				s.RecordEvent(&engineAggregateEvent{Content: c})
+			}
+		}
+
+		fx.process.RouteEventToInstanceFunc = func(
+			_ context.Context,
+			m dogma.Event,
+		) (string, bool, error) {
+			return string(m.(*engineAggregateEvent).Content), true, nil
+		}
+
+		return fx
+	}
+
+	t.Run("it handles messages for different instances concurrently", func(t *testing.T) {
+		fx := newFixture("<instance-1>", "<instance-2>")
+
+		var barrier sync.WaitGroup
+		barrier.Add(2)
+
+		fx.process.HandleEventFunc = func(
+			context.Context,
+			*ProcessRootStub,
+			dogma.ProcessEventScope[*ProcessRootStub],
+			dogma.Event,
+		) error {
+			barrier.Done()
+
+			done := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("timed out waiting for the other instance to be handled")
+			}
+
+			return nil
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithConcurrentDispatch(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("it handles messages for the same instance in order", func(t *testing.T) {
+		fx := newFixture("<instance>", "<instance>", "<instance>")
+
+		var offsets []uint64
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			_ dogma.Event,
+		) (uint64, error) {
+			offsets = append(offsets, s.Offset())
+			return s.Offset() + 1, nil
+		}
+
+		var instances []string
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			instances = append(instances, s.InstanceID())
+			return nil
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithConcurrentDispatch(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected offsets", offsets, []uint64{0, 1, 2})
+		xtesting.Expect(t, "unexpected instances", instances, []string{"<instance>", "<instance>", "<instance>"})
+	})
+
+	t.Run("it routes each message to an instance once", func(t *testing.T) {
+		fx := newFixture("<instance-1>", "<instance-2>")
+		occProjection(fx, true)
+
+		var commands, events atomic.Int32
+
+		fx.aggregate.RouteCommandToInstanceFunc = func(dogma.Command) string {
+			commands.Add(1)
+			return "<instance>"
+		}
+
+		fx.process.RouteEventToInstanceFunc = func(
+			_ context.Context,
+			m dogma.Event,
+		) (string, bool, error) {
+			events.Add(1)
+			return string(m.(*engineAggregateEvent).Content), true, nil
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithConcurrentDispatch(),
+			WithDuplicateDelivery(3),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of commands routed", commands.Load(), int32(1))
+		xtesting.Expect(t, "unexpected number of events routed", events.Load(), int32(2))
+	})
+
+	t.Run("it notifies observers in the same order as serial dispatch", func(t *testing.T) {
+		// facts returns a description of the facts produced by dispatching a
+		// command with the given options.
+		facts := func(options ...OperationOption) []string {
+			fx := newFixture("<instance-1>", "<instance-2>", "<instance-1>")
+			buf := &fact.Buffer{}
+
+			fx.process.HandleEventFunc = func(
+				_ context.Context,
+				_ *ProcessRootStub,
+				s dogma.ProcessEventScope[*ProcessRootStub],
+				_ dogma.Event,
+			) error {
+				s.ExecuteCommand(&engineIntegrationCommand{})
+				return nil
+			}
+
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineAggregateCommand{},
+				append(options, WithObserver(buf))...,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var desc []string
+			for _, f := range buf.Facts() {
+				desc = append(desc, fmt.Sprintf("%T", f))
+			}
+
+			return desc
+		}
+
+		want := facts()
+
+		for range 10 {
+			xtesting.Expect(
+				t,
+				"unexpected facts",
+				facts(WithConcurrentDispatch()),
+				want,
+			)
+		}
+	})
+
+	t.Run("it propagates panics from handlers", func(t *testing.T) {
+		fx := newFixture("<instance-1>", "<instance-2>")
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			if s.InstanceID() == "<instance-2>" {
+				panic("<panic>")
+			}
+			return nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineAggregateCommand{},
+					WithConcurrentDispatch(),
+				)
+			},
+			func(x panicx.Recovered) {
+				xtesting.Expect(t, "unexpected panic value", x.Value, any("<panic>"))
+
+				if !strings.Contains(string(x.Stack), "concurrent_test.go") {
+					t.Fatalf("expected the stack to include the handler, got:\n%s", x.Stack)
+				}
+			},
+		)
+	})
+
+	t.Run("it defers dead-letters until the message has been handled", func(t *testing.T) {
+		fx := newFixture("<instance-1>", "<instance-2>")
+		e := MustNew(fx.cfg, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			return fmt.Errorf("<error: %s>", s.InstanceID())
+		}
+
+		err := e.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithConcurrentDispatch(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var errors []string
+		for _, dl := range e.DeadLetters() {
+			errors = append(errors, dl.Error.Error())
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected dead-letters",
+			errors,
+			[]string{"<error: <instance-1>>", "<error: <instance-2>>"},
+		)
+	})
+}
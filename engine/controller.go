@@ -13,8 +13,8 @@ import (
 	"github.com/dogmatiq/testkit/fact"
 )
 
-// A controller provides Dispatch(), Tick() and Reset() functionality to the
-// engine for a single Dogma message handler.
+// A controller provides Dispatch(), Tick(), Reset(), Snapshot() and Restore()
+// functionality to the engine for a single Dogma message handler.
 type controller interface {
 	// HandlerConfig returns the config of the handler that is managed by this
 	// controller.
@@ -50,6 +50,13 @@ type controller interface {
 
 	// Reset clears the state of the controller.
 	Reset()
+
+	// Snapshot returns an opaque representation of the controller's state.
+	Snapshot() any
+
+	// Restore replaces the controller's state with a snapshot previously
+	// returned by Snapshot().
+	Restore(snapshot any)
 }
 
 func registerControllers(
@@ -66,6 +73,10 @@ func registerControllers(
 					&aggregate.Controller{
 						Config:     h,
 						MessageIDs: &e.messageIDs,
+						Events:     opts.eventStore,
+
+						SnapshotInterval: snapshotInterval(opts.snapshotPolicy),
+						VerifySnapshot:   snapshotVerifier(opts.snapshotVerification),
 					},
 				)
 			},
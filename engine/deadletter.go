@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+	"go.uber.org/multierr"
+)
+
+// DeadLetter is a message that a handler failed to handle.
+type DeadLetter struct {
+	// Handler is the handler that failed to handle the message.
+	Handler config.Handler
+
+	// Envelope contains the message that could not be handled.
+	Envelope *envelope.Envelope
+
+	// Causes is the chain of messages that caused the message in Envelope to be
+	// produced, beginning with the message that was passed to Dispatch() and
+	// ending with the message that was being handled when Envelope was
+	// produced.
+	//
+	// It is empty if the message in Envelope was itself passed to Dispatch().
+	//
+	// The engine only remembers the 10,000 messages it has most recently
+	// dispatched. If any of the causes are older than that, the chain begins
+	// with the oldest cause that is still remembered.
+	Causes []*envelope.Envelope
+
+	// Error is the error returned by the handler on the final attempt.
+	Error error
+
+	// Attempts is the number of attempts made to handle the message.
+	Attempts int
+}
+
+// DeadLetters returns the messages that handlers have failed to handle, in the
+// order they were dead-lettered.
+//
+// If the engine has a retry policy, a message is dead-lettered once the handler
+// has exhausted the attempts allowed by that policy. Otherwise, it is
+// dead-lettered as soon as the handler fails, in addition to the error being
+// returned by Dispatch() or Tick().
+func (e *Engine) DeadLetters() []DeadLetter {
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	return slices.Clone(e.deadLetters)
+}
+
+// RetryDeadLetters redelivers each dead-lettered message to the handler that
+// failed to handle it.
+//
+// It is typically used once the condition that caused the handlers to fail has
+// been fixed. Each message is removed from the dead-letters before it is
+// redelivered. If it fails again, it is retried or dead-lettered as though it
+// were being handled for the first time.
+//
+// Messages for handlers that are disabled by the operation options remain
+// dead-lettered.
+func (e *Engine) RetryDeadLetters(
+	ctx context.Context,
+	options ...OperationOption,
+) error {
+	oo := newOperationOptions(e, options)
+
+	if err := e.m.Lock(ctx); err != nil {
+		return err
+	}
+	defer e.m.Unlock()
+
+	var (
+		err     error
+		queue   []*envelope.Envelope
+		pending []DeadLetter
+	)
+
+	letters := e.deadLetters
+	e.deadLetters = nil
+
+	for i, l := range letters {
+		if ctx.Err() != nil {
+			pending = append(pending, letters[i:]...)
+			break
+		}
+
+		c := e.controllers[l.Handler.Identity().GetName()]
+
+		if skip, reason := e.skipHandler(c.HandlerConfig(), oo); skip {
+			oo.observers.Notify(
+				fact.HandlingSkipped{
+					Handler:  c.HandlerConfig(),
+					Envelope: l.Envelope,
+					Reason:   reason,
+				},
+			)
+
+			pending = append(pending, l)
+			continue
+		}
+
+		envs, cerr := e.attempt(ctx, oo, l.Envelope, c, nil, 1)
+		queue = append(queue, envs...)
+
+		if cerr != nil {
+			err = multierr.Append(
+				err,
+				fmt.Errorf(
+					"%s %s: %w",
+					c.HandlerConfig().Identity().GetName(),
+					c.HandlerConfig().HandlerType(),
+					cerr,
+				),
+			)
+		}
+	}
+
+	e.deadLetters = append(pending, e.deadLetters...)
+
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+
+	return multierr.Append(
+		err,
+		e.dispatch(ctx, oo, queue...),
+	)
+}
+
+// deadLetter adds env to the engine's dead-letters after n failed attempts to
+// handle it using c.
+func (e *Engine) deadLetter(
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	n int,
+	err error,
+) {
+	h := c.HandlerConfig()
+
+	oo.apply(func() {
+		e.deadLetters = append(
+			e.deadLetters,
+			DeadLetter{
+				Handler:  h,
+				Envelope: env,
+				Causes:   e.causes(env),
+				Error:    err,
+				Attempts: n,
+			},
+		)
+	})
+
+	oo.observers.Notify(
+		fact.MessageDeadLettered{
+			Handler:  h,
+			Envelope: env,
+			Error:    err,
+			Attempts: n,
+		},
+	)
+}
+
+// causes returns the chain of messages that caused env, as per
+// DeadLetter.Causes.
+func (e *Engine) causes(env *envelope.Envelope) []*envelope.Envelope {
+	var chain []*envelope.Envelope
+
+	for env.CausationID != env.MessageID {
+		cause, ok := e.history.get(env.CausationID)
+		if !ok {
+			break
+		}
+
+		chain = append(chain, cause)
+		env = cause
+	}
+
+	slices.Reverse(chain)
+
+	return chain
+}
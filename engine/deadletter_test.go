@@ -0,0 +1,207 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_DeadLetters(t *testing.T) {
+	t.Run("it dead-letters messages that fail when there is no retry policy", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			return errors.New("<error>")
+		}
+
+		err := fx.engine.Dispatch(context.Background(), &engineIntegrationCommand{})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		letters := fx.engine.DeadLetters()
+		if len(letters) != 1 {
+			t.Fatalf("expected 1 dead-letter, got %d", len(letters))
+		}
+
+		xtesting.Expect(t, "unexpected handler", letters[0].Handler.Identity().GetName(), "<integration>")
+		xtesting.Expect(t, "unexpected error", letters[0].Error.Error(), "<error>")
+		xtesting.Expect(t, "unexpected attempts", letters[0].Attempts, 1)
+		xtesting.Expect(t, "unexpected number of causes", len(letters[0].Causes), 0)
+	})
+
+	t.Run("it records the chain of messages that caused the dead-lettered message", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{})
+		}
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.ExecuteCommand(&engineIntegrationCommand{})
+			return nil
+		}
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			return errors.New("<error>")
+		}
+
+		_ = fx.engine.Dispatch(context.Background(), &engineAggregateCommand{})
+
+		letters := fx.engine.DeadLetters()
+		if len(letters) != 1 {
+			t.Fatalf("expected 1 dead-letter, got %d", len(letters))
+		}
+
+		causes := letters[0].Causes
+		if len(causes) != 2 {
+			t.Fatalf("expected 2 causes, got %d", len(causes))
+		}
+
+		xtesting.Expect(t, "unexpected root cause", causes[0].Message, dogma.Message(&engineAggregateCommand{}))
+		xtesting.Expect(t, "unexpected direct cause", causes[1].Message, dogma.Message(&engineAggregateEvent{}))
+		xtesting.Expect(t, "unexpected causation ID", letters[0].Envelope.CausationID, causes[1].MessageID)
+	})
+
+	t.Run("it omits causes that were dispatched more than 10,000 messages ago", func(t *testing.T) {
+		fx := newEngineFixture()
+		now := time.Now()
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.ScheduleDeadline(&engineProcessDeadline{}, now.Add(time.Hour))
+			return nil
+		}
+		fx.process.HandleDeadlineFunc = func(
+			context.Context,
+			*ProcessRootStub,
+			dogma.ProcessDeadlineScope[*ProcessRootStub],
+			dogma.Deadline,
+		) error {
+			return errors.New("<error>")
+		}
+
+		if err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			WithCurrentTime(now),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		for range 10000 {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineIntegrationCommand{},
+				WithCurrentTime(now),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_ = fx.engine.Tick(context.Background(), WithCurrentTime(now.Add(time.Hour)))
+
+		letters := fx.engine.DeadLetters()
+		if len(letters) != 1 {
+			t.Fatalf("expected 1 dead-letter, got %d", len(letters))
+		}
+
+		xtesting.Expect(t, "unexpected number of causes", len(letters[0].Causes), 0)
+	})
+}
+
+func TestEngine_RetryDeadLetters(t *testing.T) {
+	setup := func(t *testing.T) (*engineFixture, *bool, *int) {
+		fx := newEngineFixture()
+		fx.engine = MustNew(fx.cfg, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+		fail := true
+		attempts := 0
+		fx.integration.HandleCommandFunc = func(
+			_ context.Context,
+			s dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			attempts++
+			if fail {
+				return errors.New("<error>")
+			}
+			s.RecordEvent(EventB1)
+			return nil
+		}
+
+		if err := fx.engine.Dispatch(context.Background(), &engineIntegrationCommand{}); err != nil {
+			t.Fatal(err)
+		}
+
+		return fx, &fail, &attempts
+	}
+
+	t.Run("it redelivers dead-lettered messages", func(t *testing.T) {
+		fx, fail, attempts := setup(t)
+		*fail = false
+
+		if err := fx.engine.RetryDeadLetters(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 3)
+		xtesting.Expect(t, "unexpected dead-letters", len(fx.engine.DeadLetters()), 0)
+
+		events, _ := fx.engine.ReadEvents(0)
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+
+		xtesting.Expect(t, "unexpected event", events[0].Message, dogma.Message(EventB1))
+	})
+
+	t.Run("it dead-letters the message again if it fails", func(t *testing.T) {
+		fx, _, attempts := setup(t)
+
+		if err := fx.engine.RetryDeadLetters(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 4)
+		xtesting.Expect(t, "unexpected dead-letters", len(fx.engine.DeadLetters()), 1)
+	})
+
+	t.Run("it does not redeliver messages to disabled handlers", func(t *testing.T) {
+		fx, fail, attempts := setup(t)
+		*fail = false
+
+		if err := fx.engine.RetryDeadLetters(
+			context.Background(),
+			EnableIntegrations(false),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 2)
+		xtesting.Expect(t, "unexpected dead-letters", len(fx.engine.DeadLetters()), 1)
+	})
+}
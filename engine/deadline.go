@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/process"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/validation"
+)
+
+// PendingDeadline is a deadline message that a process has scheduled, but that
+// has not yet been handled.
+type PendingDeadline struct {
+	// Handler is the process that scheduled the deadline.
+	Handler *config.Process
+
+	// InstanceID is the ID of the process instance that scheduled the
+	// deadline.
+	InstanceID string
+
+	// Message is the deadline message.
+	Message dogma.Deadline
+
+	// ScheduledFor is the time at which the deadline is handled.
+	ScheduledFor time.Time
+}
+
+// PendingDeadlines returns the deadline messages that processes have scheduled
+// but that have not yet been handled, in the order they are scheduled for.
+//
+// Deadlines are handled by Tick() once the engine time reaches their scheduled
+// time. Deadlines scheduled by a process instance are canceled when the
+// instance ends.
+//
+// Deadlines scheduled by processes that are disabled, either by their
+// configuration or by the given operation options, are not returned, as they
+// are not handled by a call to Tick() that uses the same options.
+func (e *Engine) PendingDeadlines(options ...OperationOption) []PendingDeadline {
+	oo := newOperationOptions(e, options)
+
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	var deadlines []PendingDeadline
+
+	for _, c := range e.controllers {
+		if skip, _ := e.skipHandler(c.HandlerConfig(), oo); skip {
+			continue
+		}
+
+		if c, ok := c.(*process.Controller); ok {
+			for _, env := range c.Deadlines() {
+				deadlines = append(
+					deadlines,
+					PendingDeadline{
+						Handler:      c.Config,
+						InstanceID:   env.Origin.InstanceID,
+						Message:      env.Message.(dogma.Deadline),
+						ScheduledFor: env.ScheduledFor,
+					},
+				)
+			}
+		}
+	}
+
+	slices.SortStableFunc(
+		deadlines,
+		func(a, b PendingDeadline) int {
+			if c := a.ScheduledFor.Compare(b.ScheduledFor); c != 0 {
+				return c
+			}
+
+			return strings.Compare(
+				a.Handler.Identity().GetName(),
+				b.Handler.Identity().GetName(),
+			)
+		},
+	)
+
+	return deadlines
+}
+
+// DeliverDeadline delivers a deadline message directly to a process instance,
+// as though the instance had scheduled it for the engine time given by the
+// operation options.
+//
+// This allows a process's handling of deadline messages to be tested without
+// first causing the instance to schedule the deadline. The deadline is routed
+// in the same way as a scheduled deadline. If the instance has not begun, or
+// has ended, the deadline is ignored and a
+// [fact.ProcessDeadlineRoutedToEndedInstance] fact is recorded.
+//
+// It panics if the application does not have a process with the given name, if
+// the process does not schedule deadlines of the given type, or if the message
+// is invalid.
+func (e *Engine) DeliverDeadline(
+	ctx context.Context,
+	name, instanceID string,
+	m dogma.Deadline,
+	options ...OperationOption,
+) error {
+	c := e.processController(name)
+	mt := message.TypeOf(m)
+
+	if err := m.Validate(validation.DeadlineValidationScope()); err != nil {
+		panic(fmt.Sprintf("cannot deliver invalid %s message: %s", mt, err))
+	}
+
+	if !c.Config.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
+		panic(fmt.Sprintf("the %q process does not schedule %s deadlines", name, mt))
+	}
+
+	if instanceID == "" {
+		panic("instance ID must not be empty")
+	}
+
+	oo := newOperationOptions(e, options)
+	env := envelope.NewDeadline(
+		e.messageIDs.Next(),
+		m,
+		oo.now,
+		oo.now,
+		envelope.Origin{
+			Handler:     c.Config,
+			HandlerType: config.ProcessHandlerType,
+			InstanceID:  instanceID,
+		},
+	)
+
+	oo.observers.Notify(
+		fact.DispatchCycleBegun{
+			Envelope:            env,
+			EngineTime:          oo.now,
+			EnabledHandlerTypes: oo.enabledHandlerTypes,
+			EnabledHandlers:     oo.enabledHandlers,
+		},
+	)
+
+	oo.notifyOrder()
+
+	err := e.m.Lock(ctx)
+	if err == nil {
+		defer e.m.Unlock()
+		err = oo.order.wrap(e.dispatch(ctx, oo, env))
+	}
+
+	oo.observers.Notify(
+		fact.DispatchCycleCompleted{
+			Envelope:            env,
+			Error:               err,
+			EnabledHandlerTypes: oo.enabledHandlerTypes,
+			EnabledHandlers:     oo.enabledHandlers,
+		},
+	)
+
+	return err
+}
@@ -0,0 +1,249 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_PendingDeadlines(t *testing.T) {
+	now := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	setup := func(t *testing.T) *engineFixture {
+		fx := newEngineFixture()
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.ScheduleDeadline(&engineProcessDeadline{Content: "<second>"}, now.Add(2*time.Hour))
+			s.ScheduleDeadline(&engineProcessDeadline{Content: "<first>"}, now.Add(1*time.Hour))
+			return nil
+		}
+
+		if err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			WithCurrentTime(now),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		return fx
+	}
+
+	t.Run("it returns the pending deadlines in the order they are scheduled for", func(t *testing.T) {
+		fx := setup(t)
+
+		deadlines := fx.engine.PendingDeadlines()
+
+		if len(deadlines) != 2 {
+			t.Fatalf("expected 2 pending deadlines, got %d", len(deadlines))
+		}
+
+		first, second := deadlines[0], deadlines[1]
+
+		xtesting.Expect(t, "unexpected handler", first.Handler.Identity().GetName(), "<process>")
+		xtesting.Expect(t, "unexpected instance ID", first.InstanceID, "<instance>")
+		xtesting.Expect(t, "unexpected message", first.Message, dogma.Deadline(&engineProcessDeadline{Content: "<first>"}))
+		xtesting.Expect(t, "unexpected scheduled-for time", first.ScheduledFor, now.Add(1*time.Hour))
+		xtesting.Expect(t, "unexpected message", second.Message, dogma.Deadline(&engineProcessDeadline{Content: "<second>"}))
+		xtesting.Expect(t, "unexpected scheduled-for time", second.ScheduledFor, now.Add(2*time.Hour))
+	})
+
+	t.Run("it does not return deadlines that have been handled", func(t *testing.T) {
+		fx := setup(t)
+
+		if err := fx.engine.Tick(
+			context.Background(),
+			WithCurrentTime(now.Add(1*time.Hour)),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		deadlines := fx.engine.PendingDeadlines()
+
+		if len(deadlines) != 1 {
+			t.Fatalf("expected 1 pending deadline, got %d", len(deadlines))
+		}
+
+		xtesting.Expect(t, "unexpected message", deadlines[0].Message, dogma.Deadline(&engineProcessDeadline{Content: "<second>"}))
+	})
+
+	t.Run("it does not return deadlines scheduled by disabled processes", func(t *testing.T) {
+		fx := setup(t)
+
+		for _, opt := range []OperationOption{
+			EnableProcesses(false),
+			EnableHandler("<process>", false),
+		} {
+			deadlines := fx.engine.PendingDeadlines(opt)
+			xtesting.Expect(t, "unexpected number of deadlines", len(deadlines), 0)
+		}
+
+		deadlines := fx.engine.PendingDeadlines(EnableHandler("<process>", true))
+		xtesting.Expect(t, "unexpected number of deadlines", len(deadlines), 2)
+	})
+
+	t.Run("it returns nothing if there are no pending deadlines", func(t *testing.T) {
+		fx := newEngineFixture()
+		xtesting.Expect(t, "unexpected number of deadlines", len(fx.engine.PendingDeadlines()), 0)
+	})
+}
+
+func TestEngine_DeliverDeadline(t *testing.T) {
+	now := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	type delivery struct {
+		InstanceID   string
+		Message      dogma.Deadline
+		Now          time.Time
+		ScheduledFor time.Time
+	}
+
+	setup := func(t *testing.T, end bool) (*engineFixture, *[]delivery) {
+		fx := newEngineFixture()
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			if end {
+				s.End()
+			}
+			return nil
+		}
+
+		var deliveries []delivery
+		fx.process.HandleDeadlineFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessDeadlineScope[*ProcessRootStub],
+			m dogma.Deadline,
+		) error {
+			deliveries = append(deliveries, delivery{s.InstanceID(), m, s.Now(), s.ScheduledFor()})
+			return nil
+		}
+
+		if err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			WithCurrentTime(now),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		return fx, &deliveries
+	}
+
+	t.Run("it delivers the deadline to the process instance", func(t *testing.T) {
+		fx, deliveries := setup(t, false)
+
+		buf := &fact.Buffer{}
+		if err := fx.engine.DeliverDeadline(
+			context.Background(),
+			"<process>",
+			"<instance>",
+			DeadlineA1,
+			WithCurrentTime(now.Add(time.Hour)),
+			WithObserver(buf),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected deliveries",
+			*deliveries,
+			[]delivery{
+				{"<instance>", DeadlineA1, now.Add(time.Hour), now.Add(time.Hour)},
+			},
+		)
+
+		f, ok := findFact[fact.DispatchCycleBegun](buf.Facts())
+		if !ok {
+			t.Fatal("expected DispatchCycleBegun fact")
+		}
+
+		xtesting.Expect(t, "unexpected origin handler", f.Envelope.Origin.Handler.Identity().GetName(), "<process>")
+		xtesting.Expect(t, "unexpected origin instance ID", f.Envelope.Origin.InstanceID, "<instance>")
+		xtesting.Expect(t, "unexpected causation ID", f.Envelope.CausationID, f.Envelope.MessageID)
+	})
+
+	t.Run("it ignores the deadline if the instance has ended", func(t *testing.T) {
+		fx, deliveries := setup(t, true)
+
+		buf := &fact.Buffer{}
+		if err := fx.engine.DeliverDeadline(
+			context.Background(),
+			"<process>",
+			"<instance>",
+			DeadlineA1,
+			WithObserver(buf),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of deliveries", len(*deliveries), 0)
+
+		f, ok := findFact[fact.ProcessDeadlineRoutedToEndedInstance](buf.Facts())
+		if !ok {
+			t.Fatal("expected ProcessDeadlineRoutedToEndedInstance fact")
+		}
+
+		xtesting.Expect(t, "unexpected instance ID", f.InstanceID, "<instance>")
+	})
+
+	t.Run("it ignores the deadline if the instance has not begun", func(t *testing.T) {
+		fx, deliveries := setup(t, false)
+
+		buf := &fact.Buffer{}
+		if err := fx.engine.DeliverDeadline(
+			context.Background(),
+			"<process>",
+			"<other-instance>",
+			DeadlineA1,
+			WithObserver(buf),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of deliveries", len(*deliveries), 0)
+
+		if _, ok := findFact[fact.ProcessDeadlineRoutedToEndedInstance](buf.Facts()); !ok {
+			t.Fatal("expected ProcessDeadlineRoutedToEndedInstance fact")
+		}
+	})
+
+	t.Run("it panics if the handler is not a process", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<aggregate>" handler is not a process`,
+			func() {
+				_ = fx.engine.DeliverDeadline(context.Background(), "<aggregate>", "<instance>", DeadlineA1)
+			},
+		)
+	})
+
+	t.Run("it panics if the process does not schedule deadlines of the given type", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<process>" process does not schedule *stubs.DeadlineStub[TypeB] deadlines`,
+			func() {
+				_ = fx.engine.DeliverDeadline(context.Background(), "<process>", "<instance>", DeadlineB1)
+			},
+		)
+	})
+}
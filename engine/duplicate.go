@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/location"
+)
+
+// WithDuplicateDelivery returns an operation option that delivers messages n
+// times to those handlers that must handle duplicate deliveries idempotently.
+//
+// Each event is delivered n times to every process and projection that handles
+// it, and each command is delivered n times to every integration that handles
+// it. The additional deliveries are made immediately after the first delivery
+// succeeds.
+//
+// A duplicate delivery must not have any observable effect. If a handler
+// produces any commands, deadlines or events while handling a duplicate, or a
+// process schedules a deadline, ends its instance or modifies its root, the
+// engine panics with a value that describes the violation. Likewise, the
+// engine panics if a projection's CheckpointOffset() method does not report
+// that a duplicate event has already been handled, as the projection would
+// otherwise apply the event again.
+//
+// By default, each message is delivered once.
+func WithDuplicateDelivery(n int) OperationOption {
+	if n < 1 {
+		panic(fmt.Sprintf("WithDuplicateDelivery(%d): n must be positive", n))
+	}
+
+	return operationOptionFunc(func(_ *Engine, oo *operationOptions) {
+		oo.deliveries = n
+	})
+}
+
+// deliverDuplicates delivers env to c again, as per the WithDuplicateDelivery()
+// option.
+//
+// r is the result of routing env to an instance, if it has already been routed.
+func (e *Engine) deliverDuplicates(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	r *instanceRoute,
+) error {
+	h := c.HandlerConfig()
+	impl, iface, method, ok := duplicateDeliveryTarget(h, env)
+	if !ok {
+		return nil
+	}
+
+	// Only the state of the instance that env is routed to is compared, as
+	// other instances of the same handler may be handled concurrently.
+	var (
+		id       string
+		snapshot func() any
+	)
+	if sc, ok := c.(instanceSnapshotter); ok {
+		if r == nil || r.env != env {
+			r = &instanceRoute{env, sc.Route(ctx, env)}
+		}
+
+		if r.result.OK {
+			id = r.result.InstanceID
+			snapshot = func() any { return sc.InstanceSnapshot(id) }
+		}
+	}
+
+	for i := 1; i < oo.deliveries; i++ {
+		var before any
+		if snapshot != nil {
+			before = snapshot()
+		}
+
+		// A projection's checkpoint offset must show that the event has
+		// already been handled, so that the event is not applied again.
+		var alreadyHandled bool
+		doo := *oo
+		doo.observers = append(
+			slices.Clip(oo.observers),
+			fact.ObserverFunc(func(f fact.Fact) {
+				if _, ok := f.(fact.ProjectionEventAlreadyHandled); ok {
+					alreadyHandled = true
+				}
+			}),
+		)
+
+		oo.observers.Notify(
+			fact.HandlingBegun{
+				Handler:  h,
+				Envelope: env,
+			},
+		)
+
+		envs, err := e.interceptHandle(
+			ctx,
+			oo,
+			env,
+			c,
+			func(ctx context.Context, env *envelope.Envelope) ([]*envelope.Envelope, error) {
+				return e.handleRoute(ctx, &doo, env, c, r)
+			},
+		)
+
+		oo.observers.Notify(
+			fact.HandlingCompleted{
+				Handler:  h,
+				Envelope: env,
+				Error:    err,
+			},
+		)
+
+		var desc string
+		m := method
+
+		if len(envs) != 0 {
+			mt := message.TypeOf(envs[0].Message)
+			desc = fmt.Sprintf("produced a %s %s", mt, mt.Kind())
+		} else if snapshot != nil {
+			desc, _ = c.(instanceSnapshotter).InstanceChange(id, before)
+		} else if _, ok := h.(*config.Projection); ok && err == nil && !alreadyHandled {
+			desc = "did not report a checkpoint offset beyond the event"
+			m = "CheckpointOffset"
+		}
+
+		if desc != "" {
+			panic(panicx.UnexpectedBehavior{
+				Handler:        h,
+				Interface:      iface,
+				Method:         m,
+				Implementation: impl,
+				Message:        env.Message,
+				Description: fmt.Sprintf(
+					"%s when handling a duplicate delivery of the same %s",
+					desc,
+					message.TypeOf(env.Message).Kind(),
+				),
+				Location: location.OfMethod(impl, m),
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// instanceSnapshotter is an interface for controllers that can detect changes
+// to the state of a single instance.
+type instanceSnapshotter interface {
+	instanceRouter
+
+	// InstanceSnapshot returns an opaque representation of the state of the
+	// instance with the given ID.
+	InstanceSnapshot(id string) any
+
+	// InstanceChange returns a description of how the state of the instance
+	// with the given ID differs from a snapshot previously returned by
+	// InstanceSnapshot().
+	//
+	// ok is false if the state has not changed.
+	InstanceChange(id string, snapshot any) (desc string, ok bool)
+}
+
+// duplicateDeliveryTarget returns the implementation, interface and method of h
+// that handles env, if it is subject to duplicate delivery.
+func duplicateDeliveryTarget(
+	h config.Handler,
+	env *envelope.Envelope,
+) (impl any, iface, method string, ok bool) {
+	kind := message.TypeOf(env.Message).Kind()
+
+	switch h := h.(type) {
+	case *config.Process:
+		return h.Implementation(), "ProcessMessageHandler", "HandleEvent", kind == message.EventKind
+	case *config.Projection:
+		return h.Implementation(), "ProjectionMessageHandler", "HandleEvent", kind == message.EventKind
+	case *config.Integration:
+		return h.Implementation(), "IntegrationMessageHandler", "HandleCommand", kind == message.CommandKind
+	default:
+		return nil, "", "", false
+	}
+}
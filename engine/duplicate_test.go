@@ -0,0 +1,281 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestWithDuplicateDelivery(t *testing.T) {
+	t.Run("it delivers events to processes n times", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		calls := 0
+		fx.process.HandleEventFunc = func(
+			context.Context,
+			*ProcessRootStub,
+			dogma.ProcessEventScope[*ProcessRootStub],
+			dogma.Event,
+		) error {
+			calls++
+			return nil
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			WithDuplicateDelivery(3),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of calls", calls, 3)
+	})
+
+	t.Run("it does not deliver commands to aggregates more than once", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		calls := 0
+		fx.aggregate.HandleCommandFunc = func(
+			*AggregateRootStub,
+			dogma.AggregateCommandScope[*AggregateRootStub],
+			dogma.Command,
+		) {
+			calls++
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithDuplicateDelivery(3),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of calls", calls, 1)
+	})
+
+	t.Run("it skips events that projections have already applied", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		checkpoints := map[string]uint64{}
+		fx.projection.CheckpointOffsetFunc = func(
+			_ context.Context,
+			id string,
+		) (uint64, error) {
+			return checkpoints[id], nil
+		}
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			_ dogma.Event,
+		) (uint64, error) {
+			cp := s.Offset() + 1
+			checkpoints[s.StreamID()] = cp
+			return cp, nil
+		}
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProjection{},
+			WithObserver(buf),
+			WithDuplicateDelivery(2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := findFact[fact.ProjectionEventAlreadyHandled](buf.Facts()); !ok {
+			t.Fatal("expected ProjectionEventAlreadyHandled fact")
+		}
+	})
+
+	t.Run("it panics if a projection does not report that a duplicate event has already been handled", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var applied int
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			_ dogma.Event,
+		) (uint64, error) {
+			applied++
+			return s.Offset() + 1, nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineForeignEventForProjection{},
+					WithDuplicateDelivery(2),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected interface", x.Interface, "ProjectionMessageHandler")
+				xtesting.Expect(t, "unexpected method", x.Method, "CheckpointOffset")
+				xtesting.Expect(t, "unexpected implementation", x.Implementation, any(fx.projection))
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"did not report a checkpoint offset beyond the event when handling a duplicate delivery of the same event",
+				)
+			},
+		)
+
+		xtesting.Expect(t, "unexpected number of applications", applied, 2)
+	})
+
+	t.Run("it panics if a duplicate delivery produces messages", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		fx.integration.HandleCommandFunc = func(
+			_ context.Context,
+			s dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			s.RecordEvent(EventB1)
+			return nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineIntegrationCommand{},
+					WithDuplicateDelivery(2),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected interface", x.Interface, "IntegrationMessageHandler")
+				xtesting.Expect(t, "unexpected method", x.Method, "HandleCommand")
+				xtesting.Expect(t, "unexpected implementation", x.Implementation, any(fx.integration))
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"produced a *stubs.EventStub[TypeB] event when handling a duplicate delivery of the same command",
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if a duplicate delivery schedules a deadline", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.ScheduleDeadline(&engineProcessDeadline{}, s.Now().Add(time.Hour))
+			return nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineForeignEventForProcess{},
+					WithDuplicateDelivery(2),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected interface", x.Interface, "ProcessMessageHandler")
+				xtesting.Expect(t, "unexpected method", x.Method, "HandleEvent")
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"scheduled a *stubs.DeadlineStub[TypeA] deadline when handling a duplicate delivery of the same event",
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if a duplicate delivery modifies the process root", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.Mutate(func(r *ProcessRootStub) {
+				v, _ := r.Value.(string)
+				r.Value = v + "<handled>"
+			})
+			return nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineForeignEventForProcess{},
+					WithDuplicateDelivery(2),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					`modified the root of the "<instance>" instance when handling a duplicate delivery of the same event`,
+				)
+			},
+		)
+	})
+
+	t.Run("it does not panic if a duplicate delivery leaves the process root unchanged", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			_ dogma.Event,
+		) error {
+			s.Mutate(func(r *ProcessRootStub) {
+				r.Value = "<handled>"
+			})
+			return nil
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			WithDuplicateDelivery(2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("it panics if n is not positive", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"WithDuplicateDelivery(0): n must be positive",
+			func() {
+				WithDuplicateDelivery(0)
+			},
+		)
+	})
+}
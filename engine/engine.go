@@ -3,11 +3,14 @@ package engine
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/dogmatiq/cosyne"
 	"github.com/dogmatiq/dogma"
 	"github.com/dogmatiq/enginekit/config"
 	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/projection"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
 	"github.com/dogmatiq/testkit/internal/validation"
@@ -16,16 +19,31 @@ import (
 
 // Engine is an in-memory Dogma engine that is used to execute tests.
 type Engine struct {
+	app        *config.Application
+	opts       *engineOptions
 	messageIDs envelope.MessageIDGenerator
 
-	// The controllers and routes maps are static and may be read without
-	// acquiring the mutex, but m must be held to call any method on a
-	// controller, to call a resetter, or to read or write idempotencyKeys.
-	m               cosyne.Mutex
-	controllers     map[string]controller
-	routes          map[message.Type][]controller
-	resetters       []func()
-	idempotencyKeys map[string]struct{}
+	// events is the log of dispatched events. It has its own synchronization
+	// so that it can be read without acquiring m.
+	events eventLog
+
+	// The controllers and routes maps, the retry policy, the interceptors and
+	// the handler timeouts are static and may be read without acquiring the
+	// mutex, but m must be held to call any method on a controller, to call a
+	// resetter, or to read or write idempotencyKeys, history, retries or
+	// deadLetters.
+	m                    cosyne.Mutex
+	controllers          map[string]controller
+	routes               map[message.Type][]controller
+	resetters            []func()
+	idempotencyKeys      map[string]struct{}
+	history              messageHistory
+	retryPolicy          *RetryPolicy
+	interceptors         []Interceptor
+	handlerTimeout       *HandlerTimeout
+	slowHandlerThreshold time.Duration
+	retries              []retry
+	deadLetters          []DeadLetter
 }
 
 // New returns a new engine that uses the given app configuration.
@@ -33,18 +51,30 @@ func New(
 	app *config.Application,
 	options ...Option,
 ) (_ *Engine, err error) {
-	opts := newEngineOptions(options)
+	return newEngine(app, newEngineOptions(options)), nil
+}
 
+// newEngine returns a new engine that uses the given app configuration and
+// engine options.
+func newEngine(app *config.Application, opts *engineOptions) *Engine {
 	e := &Engine{
-		controllers:     map[string]controller{},
-		routes:          map[message.Type][]controller{},
-		resetters:       opts.resetters,
-		idempotencyKeys: map[string]struct{}{},
+		app:                  app,
+		opts:                 opts,
+		controllers:          map[string]controller{},
+		routes:               map[message.Type][]controller{},
+		resetters:            opts.resetters,
+		idempotencyKeys:      map[string]struct{}{},
+		retryPolicy:          opts.retryPolicy,
+		interceptors:         opts.interceptors,
+		handlerTimeout:       opts.handlerTimeout,
+		slowHandlerThreshold: opts.slowHandlerThreshold,
 	}
 
+	e.events.limit = opts.eventLogLimit
+
 	registerControllers(e, opts, app)
 
-	return e, nil
+	return e
 }
 
 // MustNew returns a new engine that uses the given app configuration, or panics
@@ -64,7 +94,11 @@ func (e *Engine) Reset() {
 	defer e.m.Unlock()
 
 	e.messageIDs.Reset()
+	e.events.restore(nil, 0)
 	clear(e.idempotencyKeys)
+	e.history.reset()
+	e.retries = nil
+	e.deadLetters = nil
 
 	for _, c := range e.controllers {
 		c.Reset()
@@ -93,10 +127,12 @@ func (e *Engine) Tick(
 		},
 	)
 
+	oo.notifyOrder()
+
 	err := e.m.Lock(ctx)
 	if err == nil {
 		defer e.m.Unlock()
-		err = e.tick(ctx, oo)
+		err = oo.order.wrap(e.tick(ctx, oo))
 	}
 
 	oo.observers.Notify(
@@ -116,7 +152,7 @@ func (e *Engine) tick(
 ) error {
 	var (
 		err   error
-		queue []*envelope.Envelope
+		queue = e.redeliver(ctx, oo)
 	)
 
 	for _, c := range e.controllers {
@@ -137,7 +173,7 @@ func (e *Engine) tick(
 			},
 		)
 
-		envs, cerr := c.Tick(ctx, oo.observers, oo.now)
+		envs, cerr := e.interceptTick(ctx, oo, c)
 		queue = append(queue, envs...)
 
 		if cerr != nil {
@@ -177,6 +213,7 @@ func (e *Engine) tick(
 // Dispatch processes a [dogma.Command] or [dogma.Event].
 //
 // It panics if the message is a [dogma.Deadline], or is otherwise invalid.
+// Use DeliverDeadline() to deliver a deadline message to a process instance.
 func (e *Engine) Dispatch(
 	ctx context.Context,
 	m dogma.Message,
@@ -215,10 +252,12 @@ func (e *Engine) Dispatch(
 		},
 	)
 
+	oo.notifyOrder()
+
 	err = e.m.Lock(ctx)
 	if err == nil {
 		defer e.m.Unlock()
-		err = e.dispatchUnlessDuplicate(ctx, oo, env)
+		err = oo.order.wrap(e.dispatchUnlessDuplicate(ctx, oo, env))
 	}
 
 	oo.observers.Notify(
@@ -261,15 +300,25 @@ func (e *Engine) dispatch(
 	oo *operationOptions,
 	queue ...*envelope.Envelope,
 ) error {
+	if oo.concurrent {
+		return e.dispatchConcurrently(ctx, oo, queue)
+	}
+
 	var err error
 
 	for len(queue) > 0 {
-		env := queue[0]
-		queue = queue[1:]
+		i := oo.order.next(queue)
+		env := queue[i]
+		queue = slices.Delete(queue, i, i+1)
 
 		var controllers []controller
 
 		mt := message.TypeOf(env.Message)
+		e.history.add(env)
+
+		if mt.Kind() == message.EventKind {
+			e.events.append(env)
+		}
 
 		if mt.Kind() == message.DeadlineKind {
 			// always dispatch deadline messages back to their origin handler
@@ -277,7 +326,7 @@ func (e *Engine) dispatch(
 				e.controllers[env.Origin.Handler.Identity().GetName()],
 			}
 		} else {
-			controllers = e.routes[mt]
+			controllers = oo.order.shuffle(e.routes[mt])
 		}
 
 		oo.observers.Notify(
@@ -288,7 +337,7 @@ func (e *Engine) dispatch(
 
 		var derr error
 		for _, c := range controllers {
-			envs, cerr := e.handle(ctx, oo, env, c)
+			envs, cerr := e.handle(ctx, oo, env, c, nil)
 			queue = append(queue, envs...)
 
 			if cerr != nil {
@@ -326,6 +375,7 @@ func (e *Engine) handle(
 	oo *operationOptions,
 	env *envelope.Envelope,
 	c controller,
+	r *instanceRoute,
 ) ([]*envelope.Envelope, error) {
 	if skip, reason := e.skipHandler(c.HandlerConfig(), oo); skip {
 		oo.observers.Notify(
@@ -339,24 +389,132 @@ func (e *Engine) handle(
 		return nil, nil
 	}
 
-	oo.observers.Notify(
-		fact.HandlingBegun{
-			Handler:  c.HandlerConfig(),
-			Envelope: env,
-		},
-	)
+	envs, err := e.attempt(ctx, oo, env, c, r, 1)
+	if err != nil {
+		return envs, err
+	}
 
-	envs, err := c.Handle(ctx, oo.observers, oo.now, env)
+	return envs, e.deliverDuplicates(ctx, oo, env, c, r)
+}
 
-	oo.observers.Notify(
-		fact.HandlingCompleted{
-			Handler:  c.HandlerConfig(),
-			Envelope: env,
-			Error:    err,
+// attempt handles env using c, beginning with the given attempt number.
+//
+// If the engine has a retry policy, failed attempts are retried or
+// dead-lettered according to that policy, and no error is returned. Otherwise,
+// a failed attempt is dead-lettered and its error is returned.
+func (e *Engine) attempt(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	r *instanceRoute,
+	n int,
+) ([]*envelope.Envelope, error) {
+	for {
+		oo.observers.Notify(
+			fact.HandlingBegun{
+				Handler:  c.HandlerConfig(),
+				Envelope: env,
+			},
+		)
+
+		envs, err := e.handleUnlessFaulted(ctx, oo, env, c, r)
+
+		oo.observers.Notify(
+			fact.HandlingCompleted{
+				Handler:  c.HandlerConfig(),
+				Envelope: env,
+				Error:    err,
+			},
+		)
+
+		if err == nil {
+			return envs, nil
+		}
+
+		if e.retryPolicy == nil {
+			e.deadLetter(oo, env, c, n, err)
+			return envs, err
+		}
+
+		if !e.retryImmediately(oo, env, c, n, err) {
+			return nil, nil
+		}
+
+		n++
+	}
+}
+
+// handleUnlessFaulted passes env to c via the engine's interceptors, unless a
+// fault is injected in its place, or into the handling itself.
+func (e *Engine) handleUnlessFaulted(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	r *instanceRoute,
+) ([]*envelope.Envelope, error) {
+	return e.interceptHandle(
+		ctx,
+		oo,
+		env,
+		c,
+		func(ctx context.Context, env *envelope.Envelope) ([]*envelope.Envelope, error) {
+			h := c.HandlerConfig()
+
+			for _, f := range oo.faults {
+				if !f.inject(h.Identity().GetName(), env) {
+					continue
+				}
+
+				if f.projection != projection.NoFault {
+					return c.(*projection.Controller).HandleWithFault(
+						ctx,
+						oo.observers,
+						oo.now,
+						env,
+						f.projection,
+					)
+				}
+
+				oo.observers.Notify(
+					fact.FaultInjected{
+						Handler:    h,
+						Envelope:   env,
+						Error:      f.err,
+						PanicValue: f.panicValue,
+					},
+				)
+
+				if f.err != nil {
+					return nil, f.err
+				}
+
+				panic(f.panicValue)
+			}
+
+			return e.handleRoute(ctx, oo, env, c, r)
 		},
 	)
+}
+
+// handleRoute passes env to c.
+//
+// If r is the result of routing env to an instance, it is passed to c so that
+// env is not routed again. Otherwise, including when an interceptor has
+// replaced the envelope, c routes env itself.
+func (e *Engine) handleRoute(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	r *instanceRoute,
+) ([]*envelope.Envelope, error) {
+	if r != nil && r.env == env {
+		return c.(instanceRouter).HandleRoute(ctx, oo.observers, oo.now, env, r.result)
+	}
 
-	return envs, err
+	return c.Handle(ctx, oo.observers, oo.now, env)
 }
 
 // skipHandler returns true if a specific handler should be skipped during a
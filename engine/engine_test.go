@@ -22,6 +22,7 @@ type (
 	engineAggregateEvent            = EventStub[TypeA]
 	engineForeignEventForProcess    = EventStub[TypeC]
 	engineForeignEventForProjection = EventStub[TypeD]
+	engineProcessDeadline           = DeadlineStub[TypeA]
 )
 
 type engineFixture struct {
@@ -57,6 +58,7 @@ func newEngineFixture() *engineFixture {
 				dogma.HandlesEvent[*engineForeignEventForProcess](),
 				dogma.HandlesEvent[*engineAggregateEvent](),
 				dogma.ExecutesCommand[*engineIntegrationCommand](),
+				dogma.SchedulesDeadline[*engineProcessDeadline](),
 			)
 		},
 		RouteEventToInstanceFunc: func(context.Context, dogma.Event) (string, bool, error) {
@@ -1,5 +1,7 @@
 package engine
 
+import "time"
+
 // Option applies optional engine-wide settings.
 type Option interface {
 	applyEngineOption(*engineOptions)
@@ -37,10 +39,32 @@ func EnableProjectionCompactionDuringHandling(enabled bool) Option {
 	})
 }
 
+// WithEventStore returns an engine option that sets the store used to persist
+// the events recorded by aggregate instances.
+//
+// By default, events are kept in memory.
+func WithEventStore(s EventStore) Option {
+	if s == nil {
+		panic("s must not be nil")
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.eventStore = s
+	})
+}
+
 // engineOptions is a container for the options set via Option values.
 type engineOptions struct {
 	resetters             []func()
 	compactDuringHandling bool
+	eventStore            EventStore
+	eventLogLimit         int
+	retryPolicy           *RetryPolicy
+	interceptors          []Interceptor
+	handlerTimeout        *HandlerTimeout
+	slowHandlerThreshold  time.Duration
+	snapshotPolicy        *SnapshotPolicy
+	snapshotVerification  *SnapshotVerification
 }
 
 // newEngineOptions returns a new engineOptions with the given options.
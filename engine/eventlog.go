@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// EventFilter is a predicate that determines which events are returned by
+// [Engine.ReadEvents].
+type EventFilter func(env *envelope.Envelope) bool
+
+// FilterByStreamID returns an EventFilter that matches events on any of the
+// given event streams.
+func FilterByStreamID(ids ...string) EventFilter {
+	return func(env *envelope.Envelope) bool {
+		return slices.Contains(ids, env.EventStreamID)
+	}
+}
+
+// FilterByMessageType returns an EventFilter that matches events of any of the
+// given message types.
+func FilterByMessageType(types ...message.Type) EventFilter {
+	return func(env *envelope.Envelope) bool {
+		return slices.Contains(types, message.TypeOf(env.Message))
+	}
+}
+
+// ReadEvents returns the events in the engine's event log, beginning at the
+// given offset.
+//
+// The event log contains every event that the engine has dispatched, whether it
+// was passed to Dispatch() or recorded by a handler, in the order that it was
+// dispatched. It also contains the events passed to SeedAggregateEvents(). The
+// first event in the log has an offset of 0.
+//
+// By default, the log keeps every event in memory for the lifetime of the
+// engine, or until it is reset. Use the WithEventLogLimit() option to bound the
+// number of events that are kept. Events that have been discarded from the log
+// are not returned, even if offset refers to them.
+//
+// If any filters are provided, only those events that match all of the filters
+// are returned.
+//
+// next is the offset at which to resume reading in order to obtain events that
+// are dispatched after this call returns.
+//
+// ReadEvents may be called concurrently with other engine operations, including
+// from within a message handler.
+func (e *Engine) ReadEvents(
+	offset uint64,
+	filters ...EventFilter,
+) (events []*envelope.Envelope, next uint64) {
+	return e.events.read(offset, filters)
+}
+
+// WithEventLogLimit returns an engine option that limits the number of events
+// kept in the engine's event log.
+//
+// Once the log contains n events, the oldest event is discarded each time a
+// new event is added. The offsets of the remaining events are unchanged.
+// Projections cannot be rebuilt by RebuildProjection() once any event has
+// been discarded.
+//
+// By default, the log is unbounded.
+func WithEventLogLimit(n int) Option {
+	if n <= 0 {
+		panic(fmt.Sprintf("event log limit must be positive, got %d", n))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.eventLogLimit = n
+	})
+}
+
+// eventLog is a global, ordered log of the events dispatched by an engine.
+type eventLog struct {
+	m sync.RWMutex
+
+	// limit is the maximum number of events to keep, or zero if the log is
+	// unbounded.
+	limit int
+
+	// offset is the offset of the first event in events. It is non-zero if
+	// events have been discarded due to the limit.
+	offset uint64
+	events []*envelope.Envelope
+}
+
+// append adds an event to the end of the log.
+func (l *eventLog) append(env *envelope.Envelope) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.events = append(l.events, env)
+	l.trim()
+}
+
+// trim discards the oldest events such that the log does not exceed its
+// limit.
+func (l *eventLog) trim() {
+	if l.limit == 0 {
+		return
+	}
+
+	for len(l.events) > l.limit {
+		l.events[0] = nil // release the envelope for garbage collection
+		l.events = l.events[1:]
+		l.offset++
+	}
+}
+
+// discarded returns the number of events that have been discarded from the
+// log due to its limit.
+func (l *eventLog) discarded() uint64 {
+	l.m.RLock()
+	defer l.m.RUnlock()
+
+	return l.offset
+}
+
+// read returns the events at or after offset that match all of the filters.
+func (l *eventLog) read(
+	offset uint64,
+	filters []EventFilter,
+) ([]*envelope.Envelope, uint64) {
+	l.m.RLock()
+	defer l.m.RUnlock()
+
+	next := l.offset + uint64(len(l.events))
+	if offset >= next {
+		return nil, next
+	}
+
+	offset = max(offset, l.offset)
+
+	var events []*envelope.Envelope
+
+outer:
+	for _, env := range l.events[offset-l.offset:] {
+		for _, f := range filters {
+			if !f(env) {
+				continue outer
+			}
+		}
+
+		events = append(events, env)
+	}
+
+	return events, next
+}
+
+// snapshot returns a copy of the events in the log, along with the offset of
+// the first event.
+func (l *eventLog) snapshot() ([]*envelope.Envelope, uint64) {
+	l.m.RLock()
+	defer l.m.RUnlock()
+
+	return slices.Clone(l.events), l.offset
+}
+
+// restore replaces the events in the log, where offset is the offset of the
+// first event.
+func (l *eventLog) restore(events []*envelope.Envelope, offset uint64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.events = slices.Clone(events)
+	l.offset = offset
+	l.trim()
+}
@@ -0,0 +1,214 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/enginekit/message"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_ReadEvents(t *testing.T) {
+	setup := func(t *testing.T) *engineFixture {
+		fx := newEngineFixture()
+
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			m dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{
+				Content: TypeA(m.(*engineAggregateCommand).Content),
+			})
+		}
+
+		for _, m := range []dogma.Message{
+			&engineAggregateCommand{Content: "<aggregate>"},
+			&engineForeignEventForProcess{Content: "<foreign>"},
+		} {
+			if err := fx.engine.Dispatch(context.Background(), m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return fx
+	}
+
+	messagesOf := func(events []*envelope.Envelope) []dogma.Message {
+		var messages []dogma.Message
+		for _, env := range events {
+			messages = append(messages, env.Message)
+		}
+		return messages
+	}
+
+	t.Run("it returns all events in the order they were dispatched", func(t *testing.T) {
+		fx := setup(t)
+
+		events, next := fx.engine.ReadEvents(0)
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			messagesOf(events),
+			[]dogma.Message{
+				&engineAggregateEvent{Content: "<aggregate>"},
+				&engineForeignEventForProcess{Content: "<foreign>"},
+			},
+		)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(2))
+	})
+
+	t.Run("it returns the events at or after the given offset", func(t *testing.T) {
+		fx := setup(t)
+
+		events, next := fx.engine.ReadEvents(1)
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			messagesOf(events),
+			[]dogma.Message{
+				&engineForeignEventForProcess{Content: "<foreign>"},
+			},
+		)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(2))
+	})
+
+	t.Run("it returns no events if the offset is at the end of the log", func(t *testing.T) {
+		fx := setup(t)
+
+		events, next := fx.engine.ReadEvents(2)
+
+		xtesting.Expect(t, "unexpected events", len(events), 0)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(2))
+	})
+
+	t.Run("it filters by stream ID", func(t *testing.T) {
+		fx := setup(t)
+
+		all, _ := fx.engine.ReadEvents(0)
+		events, _ := fx.engine.ReadEvents(0, FilterByStreamID(all[0].EventStreamID))
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			messagesOf(events),
+			[]dogma.Message{
+				&engineAggregateEvent{Content: "<aggregate>"},
+			},
+		)
+	})
+
+	t.Run("it filters by message type", func(t *testing.T) {
+		fx := setup(t)
+
+		events, _ := fx.engine.ReadEvents(
+			0,
+			FilterByMessageType(message.TypeFor[*engineForeignEventForProcess]()),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected events",
+			messagesOf(events),
+			[]dogma.Message{
+				&engineForeignEventForProcess{Content: "<foreign>"},
+			},
+		)
+	})
+
+	t.Run("it clears the log when the engine is reset", func(t *testing.T) {
+		fx := setup(t)
+		fx.engine.Reset()
+
+		events, next := fx.engine.ReadEvents(0)
+
+		xtesting.Expect(t, "unexpected events", len(events), 0)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(0))
+	})
+
+	t.Run("it restores the log from a snapshot", func(t *testing.T) {
+		fx := setup(t)
+		snapshot := fx.engine.Snapshot()
+		fx.engine.Reset()
+		fx.engine.Restore(snapshot)
+
+		_, next := fx.engine.ReadEvents(0)
+
+		xtesting.Expect(t, "unexpected next offset", next, uint64(2))
+	})
+}
+
+func TestWithEventLogLimit(t *testing.T) {
+	setup := func(t *testing.T) *engineFixture {
+		fx := newEngineFixture()
+		fx.engine = MustNew(fx.cfg, WithEventLogLimit(2))
+
+		for _, c := range []TypeC{"<first>", "<second>", "<third>"} {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineForeignEventForProcess{Content: c},
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return fx
+	}
+
+	t.Run("it discards the oldest events", func(t *testing.T) {
+		fx := setup(t)
+
+		events, next := fx.engine.ReadEvents(0)
+
+		xtesting.Expect(t, "unexpected number of events", len(events), 2)
+		xtesting.Expect(
+			t,
+			"unexpected first event",
+			events[0].Message,
+			dogma.Message(&engineForeignEventForProcess{Content: "<second>"}),
+		)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(3))
+	})
+
+	t.Run("it does not change the offsets of the remaining events", func(t *testing.T) {
+		fx := setup(t)
+
+		events, _ := fx.engine.ReadEvents(2)
+
+		xtesting.Expect(t, "unexpected number of events", len(events), 1)
+		xtesting.Expect(
+			t,
+			"unexpected event",
+			events[0].Message,
+			dogma.Message(&engineForeignEventForProcess{Content: "<third>"}),
+		)
+	})
+
+	t.Run("it preserves the offsets when restoring a snapshot", func(t *testing.T) {
+		fx := setup(t)
+		snapshot := fx.engine.Snapshot()
+		fx.engine.Reset()
+		fx.engine.Restore(snapshot)
+
+		events, next := fx.engine.ReadEvents(2)
+
+		xtesting.Expect(t, "unexpected number of events", len(events), 1)
+		xtesting.Expect(t, "unexpected next offset", next, uint64(3))
+	})
+
+	t.Run("it panics if the limit is not positive", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"event log limit must be positive, got 0",
+			func() {
+				WithEventLogLimit(0)
+			},
+		)
+	})
+}
@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// EventStore persists the events recorded by aggregate instances.
+//
+// Each engine requires its own event store. By default, events are kept in
+// memory. Use the WithEventStore() option to select a different store.
+type EventStore interface {
+	// AppendEvents appends events to the history of an aggregate instance.
+	AppendEvents(h *config.Aggregate, id string, events []*envelope.Envelope) error
+
+	// LoadEvents returns the entire history of an aggregate instance, in the
+	// order that the events were appended.
+	LoadEvents(h *config.Aggregate, id string) ([]*envelope.Envelope, error)
+
+	// DeleteEvents removes the entire history of an aggregate instance.
+	DeleteEvents(h *config.Aggregate, id string) error
+
+	// Fork returns a new, empty event store for use by an engine created by
+	// Engine.Fork().
+	//
+	// The new store must not share any events with this store, such that
+	// changes made by one engine have no effect on the other.
+	Fork() (EventStore, error)
+}
+
+// FileEventStore is an EventStore that persists events to JSONL files on disk.
+//
+// Each aggregate instance's history is stored in a separate file, named after
+// the instance ID, within a directory named after the handler's identity key.
+// Each line of the file contains a single event.
+//
+// Event messages must be in Dogma's message type registry, and must implement
+// MarshalBinary() and UnmarshalBinary().
+type FileEventStore struct {
+	dir string
+	m   sync.Mutex
+}
+
+var _ EventStore = (*FileEventStore)(nil)
+
+// NewFileEventStore returns a new event store that persists events to files
+// within dir.
+//
+// The directory is created if it does not already exist. It should not contain
+// the events of any prior engine.
+func NewFileEventStore(dir string) *FileEventStore {
+	return &FileEventStore{dir: dir}
+}
+
+// Dir returns the directory in which the events are stored.
+func (s *FileEventStore) Dir() string {
+	return s.dir
+}
+
+// AppendEvents appends events to the history of an aggregate instance.
+func (s *FileEventStore) AppendEvents(
+	h *config.Aggregate,
+	id string,
+	events []*envelope.Envelope,
+) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	path := s.path(h, id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, env := range events {
+		rec, err := marshalEventRecord(env)
+		if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadEvents returns the entire history of an aggregate instance, in the order
+// that the events were appended.
+func (s *FileEventStore) LoadEvents(
+	h *config.Aggregate,
+	id string,
+) ([]*envelope.Envelope, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	f, err := os.Open(s.path(h, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*envelope.Envelope
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	for dec.More() {
+		var rec eventRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, err
+		}
+
+		env, err := rec.unmarshal(h, id)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, env)
+	}
+
+	return events, nil
+}
+
+// DeleteEvents removes the entire history of an aggregate instance.
+func (s *FileEventStore) DeleteEvents(h *config.Aggregate, id string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	err := os.Remove(s.path(h, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Fork returns a new, empty event store that persists events to files within
+// a new subdirectory of the store's directory.
+func (s *FileEventStore) Fork() (EventStore, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(s.dir, "fork-")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileEventStore(dir), nil
+}
+
+// path returns the path to the file that contains the history of an aggregate
+// instance.
+func (s *FileEventStore) path(h *config.Aggregate, id string) string {
+	return filepath.Join(
+		s.dir,
+		h.Identity().GetKey().AsString(),
+		url.PathEscape(id)+".jsonl",
+	)
+}
+
+// eventRecord is the JSON representation of an event envelope that is written
+// by FileEventStore.
+type eventRecord struct {
+	MessageID         string    `json:"message_id"`
+	CausationID       string    `json:"causation_id"`
+	CorrelationID     string    `json:"correlation_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	EventStreamID     string    `json:"event_stream_id"`
+	EventStreamOffset uint64    `json:"event_stream_offset"`
+	Description       string    `json:"description"`
+	MessageTypeID     string    `json:"message_type_id"`
+	Data              []byte    `json:"data"`
+}
+
+// marshalEventRecord returns the record representation of env.
+func marshalEventRecord(env *envelope.Envelope) (eventRecord, error) {
+	mt, ok := dogma.RegisteredMessageTypeOf(env.Message)
+	if !ok {
+		return eventRecord{}, fmt.Errorf("%T is not in the message type registry", env.Message)
+	}
+
+	data, err := env.Message.MarshalBinary()
+	if err != nil {
+		return eventRecord{}, fmt.Errorf("unable to marshal %T: %w", env.Message, err)
+	}
+
+	return eventRecord{
+		MessageID:         env.MessageID,
+		CausationID:       env.CausationID,
+		CorrelationID:     env.CorrelationID,
+		CreatedAt:         env.CreatedAt,
+		EventStreamID:     env.EventStreamID,
+		EventStreamOffset: env.EventStreamOffset,
+		Description:       env.Message.MessageDescription(),
+		MessageTypeID:     mt.ID(),
+		Data:              data,
+	}, nil
+}
+
+// unmarshal returns the envelope represented by the record.
+//
+// h and id are the handler and instance that recorded the event.
+func (r eventRecord) unmarshal(h *config.Aggregate, id string) (*envelope.Envelope, error) {
+	mt, ok := dogma.RegisteredMessageTypeByID(r.MessageTypeID)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the message type registry", r.MessageTypeID)
+	}
+
+	m := mt.New()
+	if err := m.UnmarshalBinary(r.Data); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal %T: %w", m, err)
+	}
+
+	return &envelope.Envelope{
+		MessageID:     r.MessageID,
+		CausationID:   r.CausationID,
+		CorrelationID: r.CorrelationID,
+		Message:       m,
+		CreatedAt:     r.CreatedAt,
+		Origin: &envelope.Origin{
+			Handler:     h,
+			HandlerType: config.AggregateHandlerType,
+			InstanceID:  id,
+		},
+		EventStreamID:     r.EventStreamID,
+		EventStreamOffset: r.EventStreamOffset,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package engine_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestFileEventStore(t *testing.T) {
+	setup := func(t *testing.T) (*engineFixture, *FileEventStore) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			m dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{
+				Content: TypeA(m.(*engineAggregateCommand).Content),
+			})
+		}
+
+		store := NewFileEventStore(t.TempDir())
+		fx.engine = MustNew(
+			fx.cfg,
+			WithEventStore(store),
+		)
+
+		return fx, store
+	}
+
+	dispatch := func(t *testing.T, fx *engineFixture, c TypeA) {
+		t.Helper()
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{Content: c},
+			EnableProcesses(false),
+			EnableProjections(false),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := func(store *FileEventStore) string {
+		return filepath.Join(
+			store.Dir(),
+			"c72c106b-771e-42f8-b3e6-05452d4002ed", // aggregate handler key
+			"%3Cinstance%3E.jsonl",
+		)
+	}
+
+	t.Run("it writes one line per event", func(t *testing.T) {
+		fx, store := setup(t)
+
+		dispatch(t, fx, "<first>")
+		dispatch(t, fx, "<second>")
+
+		data, err := os.ReadFile(path(store))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		xtesting.Expect(t, "unexpected number of lines", len(lines), 2)
+	})
+
+	t.Run("it loads the events when handling subsequent commands", func(t *testing.T) {
+		fx, _ := setup(t)
+
+		dispatch(t, fx, "<first>")
+		dispatch(t, fx, "<second>")
+
+		r, ok := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<first>"},
+					&engineAggregateEvent{Content: "<second>"},
+				},
+			}),
+		)
+	})
+
+	t.Run("it restores the events from a snapshot", func(t *testing.T) {
+		fx, _ := setup(t)
+
+		dispatch(t, fx, "<first>")
+		snapshot := fx.engine.Snapshot()
+		dispatch(t, fx, "<second>")
+
+		fx.engine.Restore(snapshot)
+
+		r, _ := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<first>"},
+				},
+			}),
+		)
+	})
+
+	t.Run("it removes the files when the engine is reset", func(t *testing.T) {
+		fx, store := setup(t)
+
+		dispatch(t, fx, "<first>")
+		fx.engine.Reset()
+
+		if _, err := os.Stat(path(store)); !os.IsNotExist(err) {
+			t.Fatalf("expected file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("it gives a forked engine its own store", func(t *testing.T) {
+		fx, store := setup(t)
+
+		dispatch(t, fx, "<first>")
+
+		parent := fx.engine
+		fork, err := parent.Fork()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fx.engine = fork
+		dispatch(t, fx, "<second>")
+
+		data, err := os.ReadFile(path(store))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		xtesting.Expect(t, "unexpected number of lines in the parent's store", len(lines), 1)
+
+		r, _ := parent.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root in the parent engine",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<first>"},
+				},
+			}),
+		)
+
+		r, _ = fork.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root in the forked engine",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<first>"},
+					&engineAggregateEvent{Content: "<second>"},
+				},
+			}),
+		)
+
+		fork.Reset()
+
+		if _, err := os.Stat(path(store)); err != nil {
+			t.Fatalf("expected the parent's file to remain after the fork is reset, got %v", err)
+		}
+	})
+}
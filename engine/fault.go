@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/projection"
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// FailHandler returns an operation option that causes the named handler to
+// return err instead of handling a message.
+//
+// By default the fault is injected every time the handler is invoked. Use
+// FaultOption values to restrict when the fault occurs.
+//
+// The handler itself is not invoked when a fault is injected.
+func FailHandler(name string, err error, options ...FaultOption) OperationOption {
+	if err == nil {
+		panic("err must not be nil")
+	}
+
+	return newFault(name, err, nil, options)
+}
+
+// PanicHandler returns an operation option that causes the named handler to
+// panic with v instead of handling a message.
+//
+// By default the fault is injected every time the handler is invoked. Use
+// FaultOption values to restrict when the fault occurs.
+//
+// The handler itself is not invoked when a fault is injected.
+func PanicHandler(name string, v any, options ...FaultOption) OperationOption {
+	if v == nil {
+		panic("v must not be nil")
+	}
+
+	return newFault(name, nil, v, options)
+}
+
+// CrashProjection returns an operation option that simulates a crash of the
+// engine after the named projection handles an event, but before the engine
+// records the result.
+//
+// The result of the projection's HandleEvent() method, including any error, is
+// discarded, and the event is immediately delivered again as though the engine
+// had restarted. A correctly implemented projection reports the new checkpoint
+// offset from its CheckpointOffset() method, so that the event is not applied
+// a second time. If the projection handled the event without error, but does
+// not report the new checkpoint offset, the engine panics with a value that
+// describes the violation.
+//
+// By default the fault is injected every time the projection is invoked. Use
+// FaultOption values to restrict when the fault occurs.
+func CrashProjection(name string, options ...FaultOption) OperationOption {
+	f := newFault(name, nil, nil, options)
+	f.projection = projection.CrashBeforeCommit
+	return f
+}
+
+// StaleProjectionCheckpoint returns an operation option that delivers each
+// event to the named projection with a stale checkpoint offset, that is, one
+// that does not match the offset reported by the projection's
+// CheckpointOffset() method, before delivering it as normal.
+//
+// A correctly implemented projection detects the mismatch and does not apply
+// the event. It returns its actual checkpoint offset from HandleEvent(), which
+// is not yet past the event. If the projection instead returns a checkpoint
+// offset that is one greater than the event's offset, it has applied the event
+// despite the mismatch, and the engine panics with a value that describes the
+// violation.
+//
+// By default the fault is injected every time the projection is invoked. Use
+// FaultOption values to restrict when the fault occurs.
+func StaleProjectionCheckpoint(name string, options ...FaultOption) OperationOption {
+	f := newFault(name, nil, nil, options)
+	f.projection = projection.StaleCheckpoint
+	return f
+}
+
+// FaultOption restricts the circumstances under which a fault configured by
+// FailHandler(), PanicHandler(), CrashProjection() or
+// StaleProjectionCheckpoint() is injected.
+type FaultOption interface {
+	applyFaultOption(*fault)
+}
+
+type faultOptionFunc func(*fault)
+
+func (f faultOptionFunc) applyFaultOption(x *fault) {
+	f(x)
+}
+
+// OnInvocation returns a fault option that injects the fault only on the nth
+// eligible invocation of the handler, where the first invocation is 1.
+//
+// Invocations are counted across every operation that uses the same fault
+// option, such as FailHandler() or PanicHandler(). The copy of the option
+// returned by ForkOperationOptions() continues counting from the same point,
+// independently of the original.
+func OnInvocation(n int) FaultOption {
+	if n < 1 {
+		panic(fmt.Sprintf("OnInvocation(%d): n must be positive", n))
+	}
+
+	return faultOptionFunc(func(f *fault) {
+		f.invocation = n
+	})
+}
+
+// ForMessageType returns a fault option that injects the fault only when the
+// handler is invoked with a message of one of the given types.
+//
+// Invocations with messages of any other type are not eligible, and are not
+// counted by OnInvocation().
+func ForMessageType(types ...message.Type) FaultOption {
+	return faultOptionFunc(func(f *fault) {
+		f.messageTypes = append(f.messageTypes, types...)
+	})
+}
+
+// WithProbability returns a fault option that injects the fault on each
+// eligible invocation of the handler with probability p.
+//
+// seed is used to seed the random number generator, such that the same
+// invocations are faulted each time a test is run.
+func WithProbability(p float64, seed uint64) FaultOption {
+	if p < 0 || p > 1 {
+		panic(fmt.Sprintf("WithProbability(%v): p must be between 0 and 1", p))
+	}
+
+	return faultOptionFunc(func(f *fault) {
+		f.probability = p
+		f.source = rand.NewPCG(seed, seed)
+		f.rand = rand.New(f.source)
+	})
+}
+
+// fault is an OperationOption that injects a fault into a handler.
+//
+// It is stateful, so that invocations can be counted across operations.
+type fault struct {
+	handler      string
+	err          error
+	panicValue   any
+	invocation   int
+	messageTypes []message.Type
+	probability  float64
+	projection   projection.Fault
+
+	m           sync.Mutex
+	source      *rand.PCG
+	rand        *rand.Rand
+	invocations int
+}
+
+// newFault returns a new fault with the given options applied.
+func newFault(name string, err error, v any, options []FaultOption) *fault {
+	f := &fault{
+		handler:    name,
+		err:        err,
+		panicValue: v,
+	}
+
+	for _, opt := range options {
+		opt.applyFaultOption(f)
+	}
+
+	return f
+}
+
+// clone returns a copy of f, including the number of invocations that it has
+// counted and the state of its random number generator.
+func (f *fault) clone() *fault {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	x := &fault{
+		handler:      f.handler,
+		err:          f.err,
+		panicValue:   f.panicValue,
+		invocation:   f.invocation,
+		messageTypes: f.messageTypes,
+		probability:  f.probability,
+		projection:   f.projection,
+		invocations:  f.invocations,
+	}
+
+	if f.source != nil {
+		source := *f.source
+		x.source = &source
+		x.rand = rand.New(x.source)
+	}
+
+	return x
+}
+
+func (f *fault) applyOperationOption(e *Engine, oo *operationOptions) {
+	if f.projection != projection.NoFault {
+		e.projectionController(f.handler)
+	} else if _, ok := e.controllers[f.handler]; !ok {
+		panic(fmt.Sprintf("the application does not have a handler named %q", f.handler))
+	}
+
+	oo.faults = append(oo.faults, f)
+}
+
+// inject reports whether the fault should be injected when the handler named
+// h is invoked with env.
+func (f *fault) inject(h string, env *envelope.Envelope) bool {
+	if h != f.handler {
+		return false
+	}
+
+	if len(f.messageTypes) != 0 &&
+		!slices.Contains(f.messageTypes, message.TypeOf(env.Message)) {
+		return false
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.invocations++
+
+	if f.invocation != 0 && f.invocation != f.invocations {
+		return false
+	}
+
+	if f.rand != nil && f.rand.Float64() >= f.probability {
+		return false
+	}
+
+	return true
+}
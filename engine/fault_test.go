@@ -0,0 +1,414 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/enginekit/message"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestFailHandler(t *testing.T) {
+	t.Run("it returns the error instead of invoking the handler", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			t.Fatal("unexpected call")
+			return nil
+		}
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithObserver(buf),
+			FailHandler("<integration>", errors.New("<error>")),
+		)
+
+		xtesting.Expect(t, "unexpected error", err.Error(), "<integration> integration: <error>")
+
+		f, ok := findFact[fact.FaultInjected](buf.Facts())
+		if !ok {
+			t.Fatal("expected FaultInjected fact")
+		}
+
+		xtesting.Expect(t, "unexpected handler", f.Handler.Identity().GetName(), "<integration>")
+		xtesting.Expect(t, "unexpected error", f.Error.Error(), "<error>")
+	})
+
+	t.Run("it only injects the fault on the nth invocation", func(t *testing.T) {
+		fx := newEngineFixture()
+		option := FailHandler("<integration>", errors.New("<error>"), OnInvocation(2))
+
+		var errs []bool
+		for range 3 {
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineIntegrationCommand{},
+				option,
+			)
+			errs = append(errs, err != nil)
+		}
+
+		xtesting.Expect(t, "unexpected errors", errs, []bool{false, true, false})
+	})
+
+	t.Run("it only injects the fault for the given message types", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{})
+		}
+		option := FailHandler(
+			"<process>",
+			errors.New("<error>"),
+			ForMessageType(message.TypeFor[*engineForeignEventForProcess]()),
+		)
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			option,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProcess{},
+			option,
+		)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("it injects the fault with the given probability", func(t *testing.T) {
+		count := func() int {
+			fx := newEngineFixture()
+			option := FailHandler("<integration>", errors.New("<error>"), WithProbability(0.5, 123))
+
+			n := 0
+			for range 100 {
+				if err := fx.engine.Dispatch(
+					context.Background(),
+					&engineIntegrationCommand{},
+					option,
+				); err != nil {
+					n++
+				}
+			}
+
+			return n
+		}
+
+		n := count()
+		if n == 0 || n == 100 {
+			t.Fatalf("expected some but not all invocations to fail, got %d", n)
+		}
+
+		xtesting.Expect(t, "expected the same invocations to fail for the same seed", count(), n)
+	})
+
+	t.Run("it panics if the handler does not exist", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the application does not have a handler named "<unknown>"`,
+			func() {
+				fx.engine.Dispatch(
+					context.Background(),
+					&engineIntegrationCommand{},
+					FailHandler("<unknown>", errors.New("<error>")),
+				)
+			},
+		)
+	})
+}
+
+func TestPanicHandler(t *testing.T) {
+	t.Run("it panics instead of invoking the handler", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			t.Fatal("unexpected call")
+			return nil
+		}
+
+		xtesting.ExpectPanic(
+			t,
+			"<value>",
+			func() {
+				fx.engine.Dispatch(
+					context.Background(),
+					&engineIntegrationCommand{},
+					PanicHandler("<integration>", "<value>"),
+				)
+			},
+		)
+	})
+}
+
+// occProjection configures fx.projection to apply events to a counter,
+// using the OCC protocol if occ is true.
+//
+// It returns a pointer to the number of times that an event has been applied.
+func occProjection(fx *engineFixture, occ bool) *int {
+	var (
+		applied     int
+		checkpoints = map[string]uint64{}
+	)
+
+	fx.projection.CheckpointOffsetFunc = func(_ context.Context, id string) (uint64, error) {
+		return checkpoints[id], nil
+	}
+
+	fx.projection.HandleEventFunc = func(
+		_ context.Context,
+		s dogma.ProjectionEventScope,
+		_ dogma.Event,
+	) (uint64, error) {
+		cp := checkpoints[s.StreamID()]
+
+		if occ && cp != s.CheckpointOffset() {
+			return cp, nil
+		}
+
+		applied++
+		checkpoints[s.StreamID()] = s.Offset() + 1
+
+		return s.Offset() + 1, nil
+	}
+
+	return &applied
+}
+
+func TestCrashProjection(t *testing.T) {
+	t.Run("it redelivers the event after discarding the result", func(t *testing.T) {
+		fx := newEngineFixture()
+		applied := occProjection(fx, true)
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProjection{},
+			WithObserver(buf),
+			CrashProjection("<projection>"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of applications", *applied, 1)
+
+		if _, ok := findFact[fact.ProjectionCrashSimulated](buf.Facts()); !ok {
+			t.Fatal("expected ProjectionCrashSimulated fact")
+		}
+
+		if _, ok := findFact[fact.ProjectionEventAlreadyHandled](buf.Facts()); !ok {
+			t.Fatal("expected ProjectionEventAlreadyHandled fact")
+		}
+	})
+
+	t.Run("it panics if the projection does not report the new checkpoint offset", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var applied int
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			_ dogma.Event,
+		) (uint64, error) {
+			applied++
+			return s.Offset() + 1, nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineForeignEventForProjection{},
+					CrashProjection("<projection>"),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected interface", x.Interface, "ProjectionMessageHandler")
+				xtesting.Expect(t, "unexpected method", x.Method, "CheckpointOffset")
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"reported a checkpoint offset of 0 after handling the event at offset 0, so the event would be applied again after a crash",
+				)
+			},
+		)
+
+		xtesting.Expect(t, "unexpected number of applications", applied, 1)
+	})
+
+	t.Run("it redelivers the event as normal if the handler fails", func(t *testing.T) {
+		fx := newEngineFixture()
+		applied := occProjection(fx, true)
+
+		fails := 1
+		handle := fx.projection.HandleEventFunc
+		fx.projection.HandleEventFunc = func(
+			ctx context.Context,
+			s dogma.ProjectionEventScope,
+			m dogma.Event,
+		) (uint64, error) {
+			if fails > 0 {
+				fails--
+				return 0, errors.New("<error>")
+			}
+			return handle(ctx, s, m)
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProjection{},
+			CrashProjection("<projection>"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of applications", *applied, 1)
+	})
+
+	t.Run("it panics if the handler is not a projection", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<integration>" handler is not a projection`,
+			func() {
+				fx.engine.Dispatch(
+					context.Background(),
+					&engineIntegrationCommand{},
+					CrashProjection("<integration>"),
+				)
+			},
+		)
+	})
+}
+
+func TestStaleProjectionCheckpoint(t *testing.T) {
+	t.Run("it does not apply the event again if the projection uses OCC", func(t *testing.T) {
+		fx := newEngineFixture()
+		applied := occProjection(fx, true)
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineForeignEventForProjection{},
+			WithObserver(buf),
+			StaleProjectionCheckpoint("<projection>"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of applications", *applied, 1)
+
+		f, ok := findFact[fact.ProjectionStaleCheckpointDelivered](buf.Facts())
+		if !ok {
+			t.Fatal("expected ProjectionStaleCheckpointDelivered fact")
+		}
+
+		xtesting.Expect(t, "unexpected checkpoint offset", f.CheckpointOffset, uint64(1))
+	})
+
+	t.Run("it panics if the projection does not use OCC", func(t *testing.T) {
+		fx := newEngineFixture()
+		applied := occProjection(fx, false)
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				_ = fx.engine.Dispatch(
+					context.Background(),
+					&engineForeignEventForProjection{},
+					StaleProjectionCheckpoint("<projection>"),
+				)
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected interface", x.Interface, "ProjectionMessageHandler")
+				xtesting.Expect(t, "unexpected method", x.Method, "HandleEvent")
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"applied the event at offset 0 even though the engine's checkpoint offset of 1 did not match the projection's checkpoint offset of 0",
+				)
+			},
+		)
+
+		xtesting.Expect(t, "unexpected number of applications", *applied, 1)
+	})
+}
+
+func TestForkOperationOptions(t *testing.T) {
+	// outcomes dispatches n commands to the integration in fx using options,
+	// and returns whether each dispatch failed.
+	outcomes := func(fx *engineFixture, n int, options []OperationOption) []bool {
+		var errs []bool
+		for range n {
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineIntegrationCommand{},
+				options...,
+			)
+			errs = append(errs, err != nil)
+		}
+		return errs
+	}
+
+	t.Run("it copies the number of invocations counted by a fault", func(t *testing.T) {
+		fx := newEngineFixture()
+		options := []OperationOption{
+			FailHandler("<integration>", errors.New("<error>"), OnInvocation(2)),
+		}
+
+		outcomes(fx, 1, options)
+		forked := ForkOperationOptions(options)
+
+		xtesting.Expect(t, "unexpected errors from the original options", outcomes(fx, 2, options), []bool{true, false})
+		xtesting.Expect(t, "unexpected errors from the forked options", outcomes(fx, 2, forked), []bool{true, false})
+	})
+
+	t.Run("it copies the state of a fault's random number generator", func(t *testing.T) {
+		fx := newEngineFixture()
+		options := []OperationOption{
+			FailHandler("<integration>", errors.New("<error>"), WithProbability(0.5, 123)),
+		}
+
+		outcomes(fx, 10, options)
+		forked := ForkOperationOptions(options)
+
+		xtesting.Expect(
+			t,
+			"expected the same invocations to fail",
+			outcomes(fx, 20, forked),
+			outcomes(fx, 20, options),
+		)
+	})
+}
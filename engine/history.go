@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// historySize is the number of dispatched messages that the engine remembers
+// in order to build the chain of causes of a dead-lettered message.
+const historySize = 10000
+
+// messageHistory is a record of the most recent messages dispatched by the
+// engine, used to build DeadLetter.Causes.
+//
+// Only the last historySize messages are kept, so that the engine does not
+// retain every message it has ever dispatched.
+type messageHistory struct {
+	envelopes map[string]*envelope.Envelope
+
+	// order contains the IDs of the messages in envelopes. Once it is full,
+	// it is used as a ring buffer in which next is the index of the oldest
+	// message.
+	order []string
+	next  int
+}
+
+// add records env as having been dispatched, forgetting the oldest message if
+// the history is full.
+func (h *messageHistory) add(env *envelope.Envelope) {
+	if _, ok := h.envelopes[env.MessageID]; ok {
+		h.envelopes[env.MessageID] = env
+		return
+	}
+
+	if h.envelopes == nil {
+		h.envelopes = map[string]*envelope.Envelope{}
+	}
+
+	if len(h.order) < historySize {
+		h.order = append(h.order, env.MessageID)
+	} else {
+		delete(h.envelopes, h.order[h.next])
+		h.order[h.next] = env.MessageID
+		h.next = (h.next + 1) % historySize
+	}
+
+	h.envelopes[env.MessageID] = env
+}
+
+// get returns the message with the given ID.
+//
+// ok is false if the message has not been dispatched, or if it has been
+// forgotten.
+func (h *messageHistory) get(id string) (env *envelope.Envelope, ok bool) {
+	env, ok = h.envelopes[id]
+	return env, ok
+}
+
+// clone returns a copy of the history.
+func (h *messageHistory) clone() messageHistory {
+	return messageHistory{
+		envelopes: maps.Clone(h.envelopes),
+		order:     slices.Clone(h.order),
+		next:      h.next,
+	}
+}
+
+// reset forgets every message.
+func (h *messageHistory) reset() {
+	*h = messageHistory{}
+}
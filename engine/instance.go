@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/aggregate"
+	"github.com/dogmatiq/testkit/engine/internal/process"
+	"github.com/dogmatiq/testkit/internal/validation"
+)
+
+// AggregateInstanceIDs returns the IDs of the instances of the named aggregate
+// that have recorded at least one event, in lexical order.
+//
+// It panics if the application does not have an aggregate with the given name.
+func (e *Engine) AggregateInstanceIDs(handler string) []string {
+	c := e.aggregateController(handler)
+
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	return c.InstanceIDs()
+}
+
+// AggregateRoot returns the root of an aggregate instance.
+//
+// The root is rebuilt by applying every event that the instance has recorded
+// to a new root. It does not modify the state of the engine.
+//
+// ok is false if the instance has not recorded any events.
+//
+// It panics if the application does not have an aggregate with the given name.
+func (e *Engine) AggregateRoot(handler, id string) (r dogma.AggregateRoot, ok bool) {
+	c := e.aggregateController(handler)
+
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	return c.Root(id)
+}
+
+// SeedAggregateEvents appends events to the history of an aggregate instance,
+// as though the instance had recorded them at the engine time given by the
+// operation options.
+//
+// The events are applied to the instance's root by calling its ApplyEvent()
+// method, but the handler's HandleCommand() method is not called, and the
+// events are not dispatched to any other handlers. They are, however, added to
+// the engine's event log, and so are returned by ReadEvents() and redelivered
+// by RebuildProjection().
+//
+// This allows an aggregate instance to be placed into a particular state
+// without executing the commands that would otherwise cause it to record the
+// events.
+//
+// It panics if the application does not have an aggregate with the given name,
+// if the aggregate does not record events of the given types, or if any of the
+// messages are invalid.
+func (e *Engine) SeedAggregateEvents(
+	ctx context.Context,
+	handler, id string,
+	events []dogma.Event,
+	options ...OperationOption,
+) error {
+	c := e.aggregateController(handler)
+
+	for _, m := range events {
+		mt := message.TypeOf(m)
+
+		if err := m.Validate(validation.EventValidationScope()); err != nil {
+			panic(fmt.Sprintf("cannot seed invalid %s message: %s", mt, err))
+		}
+
+		if !c.Config.RouteSet().DirectionOf(mt).Has(config.OutboundDirection) {
+			panic(fmt.Sprintf("the %q aggregate does not record %s events", handler, mt))
+		}
+	}
+
+	if id == "" {
+		panic("instance ID must not be empty")
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	oo := newOperationOptions(e, options)
+
+	if err := e.m.Lock(ctx); err != nil {
+		return err
+	}
+	defer e.m.Unlock()
+
+	envs, err := c.Seed(oo.observers, oo.now, id, events)
+	if err != nil {
+		return fmt.Errorf(
+			"%s %s: %w",
+			handler,
+			config.AggregateHandlerType,
+			err,
+		)
+	}
+
+	for _, env := range envs {
+		e.history.add(env)
+		e.events.append(env)
+	}
+
+	return nil
+}
+
+// ProcessInstanceIDs returns the IDs of the instances of the named process that
+// have begun, including those that have since ended, in lexical order.
+//
+// It panics if the application does not have a process with the given name.
+func (e *Engine) ProcessInstanceIDs(handler string) []string {
+	c := e.processController(handler)
+
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	return c.InstanceIDs()
+}
+
+// ProcessRoot returns the root of a process instance.
+//
+// The root is rebuilt from the instance's serialized state. It does not modify
+// the state of the engine.
+//
+// ok is false if the instance has never begun. ended is true if the instance
+// has ended.
+//
+// It panics if the application does not have a process with the given name.
+func (e *Engine) ProcessRoot(handler, id string) (r dogma.ProcessRoot, ended, ok bool) {
+	c := e.processController(handler)
+
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	return c.Root(id)
+}
+
+// aggregateController returns the controller for the named aggregate.
+func (e *Engine) aggregateController(name string) *aggregate.Controller {
+	c, ok := e.controllers[name]
+	if !ok {
+		panic(fmt.Sprintf("the application does not have a handler named %q", name))
+	}
+
+	if c, ok := c.(*aggregate.Controller); ok {
+		return c
+	}
+
+	panic(fmt.Sprintf("the %q handler is not an aggregate", name))
+}
+
+// processController returns the controller for the named process.
+func (e *Engine) processController(name string) *process.Controller {
+	c, ok := e.controllers[name]
+	if !ok {
+		panic(fmt.Sprintf("the application does not have a handler named %q", name))
+	}
+
+	if c, ok := c.(*process.Controller); ok {
+		return c
+	}
+
+	panic(fmt.Sprintf("the %q handler is not a process", name))
+}
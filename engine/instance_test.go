@@ -0,0 +1,331 @@
+package engine_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_AggregateRoot(t *testing.T) {
+	t.Run("it returns the root of an existing instance", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{Content: "<event>"})
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			EnableProcesses(false),
+			EnableProjections(false),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, ok := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<event>"},
+				},
+			}),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected instance IDs",
+			fx.engine.AggregateInstanceIDs("<aggregate>"),
+			[]string{"<instance>"},
+		)
+	})
+
+	t.Run("it returns false if the instance does not exist", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		if _, ok := fx.engine.AggregateRoot("<aggregate>", "<instance>"); ok {
+			t.Fatal("did not expect instance to exist")
+		}
+	})
+
+	t.Run("it panics if the handler does not exist", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the application does not have a handler named "<unknown>"`,
+			func() {
+				fx.engine.AggregateRoot("<unknown>", "<instance>")
+			},
+		)
+	})
+
+	t.Run("it panics if the handler is not an aggregate", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<process>" handler is not an aggregate`,
+			func() {
+				fx.engine.AggregateInstanceIDs("<process>")
+			},
+		)
+	})
+}
+
+func TestEngine_SeedAggregateEvents(t *testing.T) {
+	t.Run("it applies the events without handling any messages", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			*AggregateRootStub,
+			dogma.AggregateCommandScope[*AggregateRootStub],
+			dogma.Command,
+		) {
+			t.Fatal("unexpected call to HandleCommand()")
+		}
+		fx.process.HandleEventFunc = func(
+			context.Context,
+			*ProcessRootStub,
+			dogma.ProcessEventScope[*ProcessRootStub],
+			dogma.Event,
+		) error {
+			t.Fatal("unexpected call to HandleEvent()")
+			return nil
+		}
+
+		if err := fx.engine.SeedAggregateEvents(
+			context.Background(),
+			"<aggregate>",
+			"<instance>",
+			[]dogma.Event{
+				&engineAggregateEvent{Content: "<first>"},
+				&engineAggregateEvent{Content: "<second>"},
+			},
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		r, ok := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<first>"},
+					&engineAggregateEvent{Content: "<second>"},
+				},
+			}),
+		)
+	})
+
+	t.Run("it notifies the observer of each seeded event", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var facts []fact.EventSeededIntoAggregate
+		if err := fx.engine.SeedAggregateEvents(
+			context.Background(),
+			"<aggregate>",
+			"<instance>",
+			[]dogma.Event{
+				&engineAggregateEvent{Content: "<first>"},
+				&engineAggregateEvent{Content: "<second>"},
+			},
+			WithObserver(fact.ObserverFunc(func(f fact.Fact) {
+				if f, ok := f.(fact.EventSeededIntoAggregate); ok {
+					facts = append(facts, f)
+				}
+			})),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(facts) != 2 {
+			t.Fatalf("expected 2 facts, got %d", len(facts))
+		}
+
+		for i, f := range facts {
+			xtesting.Expect(t, "unexpected instance ID", f.InstanceID, "<instance>")
+			xtesting.Expect(t, "unexpected handler", f.Handler.Identity().GetName(), "<aggregate>")
+			xtesting.Expect(t, "unexpected event offset", f.EventEnvelope.EventStreamOffset, uint64(i))
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected event",
+			facts[1].EventEnvelope.Message,
+			dogma.Message(&engineAggregateEvent{Content: "<second>"}),
+		)
+	})
+
+	t.Run("it adds the events to the instance's event stream", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var applied []dogma.Event
+		fx.aggregate.HandleCommandFunc = func(
+			r *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			applied = slices.Clone(r.AppliedEvents)
+			s.RecordEvent(&engineAggregateEvent{Content: "<recorded>"})
+		}
+
+		if err := fx.engine.SeedAggregateEvents(
+			context.Background(),
+			"<aggregate>",
+			"<instance>",
+			[]dogma.Event{
+				&engineAggregateEvent{Content: "<seeded>"},
+			},
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			EnableProcesses(false),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected events applied before handling the command",
+			applied,
+			[]dogma.Event{&engineAggregateEvent{Content: "<seeded>"}},
+		)
+
+		events, _ := fx.engine.ReadEvents(0)
+
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(events))
+		}
+
+		seeded, recorded := events[0], events[1]
+
+		xtesting.Expect(t, "unexpected message", seeded.Message, dogma.Message(&engineAggregateEvent{Content: "<seeded>"}))
+		xtesting.Expect(t, "unexpected origin instance ID", seeded.Origin.InstanceID, "<instance>")
+		xtesting.Expect(t, "unexpected stream ID", recorded.EventStreamID, seeded.EventStreamID)
+		xtesting.Expect(t, "unexpected offset", seeded.EventStreamOffset, uint64(0))
+		xtesting.Expect(t, "unexpected offset", recorded.EventStreamOffset, uint64(1))
+	})
+
+	t.Run("it panics if the aggregate does not record the event type", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<aggregate>" aggregate does not record *stubs.EventStub[TypeX] events`,
+			func() {
+				_ = fx.engine.SeedAggregateEvents(
+					context.Background(),
+					"<aggregate>",
+					"<instance>",
+					[]dogma.Event{EventX1},
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if the handler is not an aggregate", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<process>" handler is not an aggregate`,
+			func() {
+				_ = fx.engine.SeedAggregateEvents(
+					context.Background(),
+					"<process>",
+					"<instance>",
+					[]dogma.Event{EventA1},
+				)
+			},
+		)
+	})
+}
+
+func TestEngine_ProcessRoot(t *testing.T) {
+	t.Run("it returns the root of an existing instance", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			m dogma.Event,
+		) error {
+			if m.(*engineForeignEventForProcess).Content == "<end>" {
+				s.End()
+			} else {
+				s.Mutate(func(r *ProcessRootStub) {
+					r.Value = "<value>"
+				})
+			}
+			return nil
+		}
+
+		for _, c := range []TypeC{"<mutate>", "<end>"} {
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineForeignEventForProcess{Content: c},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		r, ended, ok := fx.engine.ProcessRoot("<process>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(t, "unexpected ended flag", ended, true)
+		xtesting.Expect(
+			t,
+			"unexpected instance IDs",
+			fx.engine.ProcessInstanceIDs("<process>"),
+			[]string{"<instance>"},
+		)
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.ProcessRoot(&ProcessRootStub{Value: "<value>"}),
+		)
+	})
+
+	t.Run("it panics if the handler is not a process", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<aggregate>" handler is not a process`,
+			func() {
+				fx.engine.ProcessRoot("<aggregate>", "<instance>")
+			},
+		)
+	})
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// Interceptor intercepts the engine's calls to message handlers.
+//
+// Interceptors may be called concurrently if the WithConcurrentDispatch()
+// operation option is used.
+type Interceptor interface {
+	// InterceptHandle is called whenever the engine passes a message to a
+	// handler.
+	//
+	// h is the handler that is to handle env, and now is the current engine
+	// time. next handles the message as it would be handled without this
+	// interceptor. The interceptor may call next with a different envelope, or
+	// not at all.
+	//
+	// It returns the messages produced by the handler that need to be
+	// dispatched by the engine.
+	InterceptHandle(
+		ctx context.Context,
+		h config.Handler,
+		env *envelope.Envelope,
+		now time.Time,
+		next HandleFunc,
+	) ([]*envelope.Envelope, error)
+
+	// InterceptTick is called whenever the engine performs a "tick" of a
+	// handler.
+	//
+	// h is the handler that is to be ticked, and now is the current engine
+	// time. next performs the tick as it would be performed without this
+	// interceptor.
+	//
+	// It returns the messages produced by the handler that need to be
+	// dispatched by the engine.
+	InterceptTick(
+		ctx context.Context,
+		h config.Handler,
+		now time.Time,
+		next TickFunc,
+	) ([]*envelope.Envelope, error)
+}
+
+// HandleFunc is a function that handles a message, as passed to
+// Interceptor.InterceptHandle().
+type HandleFunc func(
+	ctx context.Context,
+	env *envelope.Envelope,
+) ([]*envelope.Envelope, error)
+
+// TickFunc is a function that performs a "tick" of a handler, as passed to
+// Interceptor.InterceptTick().
+type TickFunc func(
+	ctx context.Context,
+) ([]*envelope.Envelope, error)
+
+// WithInterceptor returns an engine option that registers an interceptor that
+// is called whenever the engine calls a message handler.
+//
+// Multiple interceptors can be registered. The first interceptor registered is
+// the outermost, that is, it is called first and its next function invokes the
+// second interceptor, and so on. Any faults injected by FailHandler() or
+// PanicHandler() occur within the innermost interceptor.
+func WithInterceptor(i Interceptor) Option {
+	if i == nil {
+		panic("i must not be nil")
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.interceptors = append(eo.interceptors, i)
+	})
+}
+
+// interceptHandle calls handle via the engine's interceptors, subject to the
+// engine's handler timeout and slow handler threshold.
+func (e *Engine) interceptHandle(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	handle HandleFunc,
+) ([]*envelope.Envelope, error) {
+	h := c.HandlerConfig()
+
+	ctx, cancel := e.handlerContext(ctx)
+	defer cancel()
+	defer e.detectSlowHandler(oo, h, env, time.Now())
+
+	for i := len(e.interceptors) - 1; i >= 0; i-- {
+		next := handle
+		handle = func(ctx context.Context, env *envelope.Envelope) ([]*envelope.Envelope, error) {
+			return e.interceptors[i].InterceptHandle(ctx, h, env, oo.now, next)
+		}
+	}
+
+	return handle(ctx, env)
+}
+
+// interceptTick performs a tick of c via the engine's interceptors, subject to
+// the engine's handler timeout and slow handler threshold.
+func (e *Engine) interceptTick(
+	ctx context.Context,
+	oo *operationOptions,
+	c controller,
+) ([]*envelope.Envelope, error) {
+	h := c.HandlerConfig()
+
+	ctx, cancel := e.handlerContext(ctx)
+	defer cancel()
+	defer e.detectSlowHandler(oo, h, nil, time.Now())
+
+	tick := func(ctx context.Context) ([]*envelope.Envelope, error) {
+		return c.Tick(ctx, oo.observers, oo.now)
+	}
+
+	for i := len(e.interceptors) - 1; i >= 0; i-- {
+		next := tick
+		tick = func(ctx context.Context) ([]*envelope.Envelope, error) {
+			return e.interceptors[i].InterceptTick(ctx, h, oo.now, next)
+		}
+	}
+
+	return tick(ctx)
+}
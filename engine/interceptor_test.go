@@ -0,0 +1,283 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+// interceptorStub is a test implementation of the Interceptor interface that
+// calls the next function unless the corresponding field is set.
+type interceptorStub struct {
+	InterceptHandleFunc func(context.Context, config.Handler, *envelope.Envelope, time.Time, HandleFunc) ([]*envelope.Envelope, error)
+	InterceptTickFunc   func(context.Context, config.Handler, time.Time, TickFunc) ([]*envelope.Envelope, error)
+}
+
+func (s *interceptorStub) InterceptHandle(
+	ctx context.Context,
+	h config.Handler,
+	env *envelope.Envelope,
+	now time.Time,
+	next HandleFunc,
+) ([]*envelope.Envelope, error) {
+	if s.InterceptHandleFunc != nil {
+		return s.InterceptHandleFunc(ctx, h, env, now, next)
+	}
+	return next(ctx, env)
+}
+
+func (s *interceptorStub) InterceptTick(
+	ctx context.Context,
+	h config.Handler,
+	now time.Time,
+	next TickFunc,
+) ([]*envelope.Envelope, error) {
+	if s.InterceptTickFunc != nil {
+		return s.InterceptTickFunc(ctx, h, now, next)
+	}
+	return next(ctx)
+}
+
+func TestWithInterceptor(t *testing.T) {
+	t.Run("it is called with the handler, envelope and engine time", func(t *testing.T) {
+		fx := newEngineFixture()
+		now := time.Now()
+
+		var (
+			handlers []string
+			messages []dogma.Message
+			times    []time.Time
+		)
+
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(&interceptorStub{
+				InterceptHandleFunc: func(
+					ctx context.Context,
+					h config.Handler,
+					env *envelope.Envelope,
+					now time.Time,
+					next HandleFunc,
+				) ([]*envelope.Envelope, error) {
+					handlers = append(handlers, h.Identity().GetName())
+					messages = append(messages, env.Message)
+					times = append(times, now)
+					return next(ctx, env)
+				},
+			}),
+		)
+
+		err := e.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithCurrentTime(now),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected handlers", handlers, []string{"<integration>"})
+		xtesting.Expect(t, "unexpected messages", messages, []dogma.Message{&engineIntegrationCommand{}})
+		xtesting.Expect(t, "unexpected times", times, []time.Time{now})
+	})
+
+	t.Run("it can handle the message in place of the handler", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			t.Fatal("unexpected call")
+			return nil
+		}
+
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(&interceptorStub{
+				InterceptHandleFunc: func(
+					context.Context,
+					config.Handler,
+					*envelope.Envelope,
+					time.Time,
+					HandleFunc,
+				) ([]*envelope.Envelope, error) {
+					return nil, errors.New("<error>")
+				},
+			}),
+		)
+
+		err := e.Dispatch(context.Background(), &engineIntegrationCommand{})
+
+		xtesting.Expect(t, "unexpected error", err.Error(), "<integration> integration: <error>")
+	})
+
+	t.Run("it can modify the messages produced by the handler", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{})
+		}
+
+		fx.projection.HandleEventFunc = func(
+			context.Context,
+			dogma.ProjectionEventScope,
+			dogma.Event,
+		) (uint64, error) {
+			t.Fatal("unexpected call")
+			return 0, nil
+		}
+
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(&interceptorStub{
+				InterceptHandleFunc: func(
+					ctx context.Context,
+					_ config.Handler,
+					env *envelope.Envelope,
+					_ time.Time,
+					next HandleFunc,
+				) ([]*envelope.Envelope, error) {
+					_, err := next(ctx, env)
+					return nil, err
+				},
+			}),
+		)
+
+		err := e.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			EnableProcesses(false),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("it calls interceptors in the order they were registered", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var order []string
+		interceptor := func(name string) Interceptor {
+			return &interceptorStub{
+				InterceptHandleFunc: func(
+					ctx context.Context,
+					_ config.Handler,
+					env *envelope.Envelope,
+					_ time.Time,
+					next HandleFunc,
+				) ([]*envelope.Envelope, error) {
+					order = append(order, name+" before")
+					defer func() { order = append(order, name+" after") }()
+					return next(ctx, env)
+				},
+			}
+		}
+
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(interceptor("<first>")),
+			WithInterceptor(interceptor("<second>")),
+		)
+
+		err := e.Dispatch(context.Background(), &engineIntegrationCommand{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected order",
+			order,
+			[]string{
+				"<first> before",
+				"<second> before",
+				"<second> after",
+				"<first> after",
+			},
+		)
+	})
+
+	t.Run("it sees the effect of injected faults", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var got error
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(&interceptorStub{
+				InterceptHandleFunc: func(
+					ctx context.Context,
+					_ config.Handler,
+					env *envelope.Envelope,
+					_ time.Time,
+					next HandleFunc,
+				) ([]*envelope.Envelope, error) {
+					envs, err := next(ctx, env)
+					got = err
+					return envs, err
+				},
+			}),
+		)
+
+		_ = e.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			FailHandler("<integration>", errors.New("<error>")),
+		)
+
+		xtesting.Expect(t, "unexpected error", got.Error(), "<error>")
+	})
+
+	t.Run("it intercepts ticks", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var handlers []string
+		e := MustNew(
+			fx.cfg,
+			WithInterceptor(&interceptorStub{
+				InterceptTickFunc: func(
+					ctx context.Context,
+					h config.Handler,
+					_ time.Time,
+					next TickFunc,
+				) ([]*envelope.Envelope, error) {
+					handlers = append(handlers, h.Identity().GetName())
+					return next(ctx)
+				},
+			}),
+		)
+
+		if err := e.Tick(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.ExpectSet(
+			t,
+			"unexpected handlers",
+			handlers,
+			[]string{"<aggregate>", "<process>", "<integration>", "<projection>"},
+			func(a, b string) bool { return a < b },
+		)
+	})
+
+	t.Run("it panics if the interceptor is nil", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"i must not be nil",
+			func() {
+				WithInterceptor(nil)
+			},
+		)
+	})
+}
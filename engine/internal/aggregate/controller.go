@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/dogmatiq/dogma"
@@ -11,6 +14,7 @@ import (
 	"github.com/dogmatiq/enginekit/message"
 	"github.com/dogmatiq/enginekit/protobuf/uuidpb"
 	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/engine/internal/routing"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
 	"github.com/dogmatiq/testkit/internal/compare"
@@ -19,8 +23,9 @@ import (
 )
 
 type instance struct {
-	// history is the set of event envelopes recorded by this instance.
-	history []*envelope.Envelope
+	// length is the number of events recorded by this instance. The events
+	// themselves are kept in the controller's event store.
+	length int
 
 	// snapshotted is true if a snapshot of the aggregate root has been taken.
 	snapshotted bool
@@ -30,10 +35,43 @@ type instance struct {
 	// events. nil/empty is valid.
 	snapshot []byte
 
-	// snapshotOffset is the index into history at which the snapshot was
+	// snapshotOffset is the index into the history at which the snapshot was
 	// taken. Events before this offset are covered by the snapshot and do not
 	// need to be replayed.
 	snapshotOffset int
+
+	// root and shadowRoot are the instance's aggregate roots as they were at
+	// the end of the last successful call to the handler. They are nil if the
+	// roots need to be rebuilt from the snapshot and history.
+	//
+	// They are removed from the instance while a command is being handled, so
+	// that they are discarded if the handler fails.
+	root, shadowRoot dogma.AggregateRoot
+
+	// copyRoot is a third root, kept up to date in the same way as root and
+	// shadowRoot, that is given to AggregateInstanceLoaded facts in place of a
+	// copy of root if root does not support marshaling. It is nil until it is
+	// first needed, and whenever root is nil.
+	copyRoot dogma.AggregateRoot
+}
+
+// clone returns a deep copy of the instance.
+//
+// The roots are not copied, as there is no general way to do so. Instead, they
+// are rebuilt from the snapshot and history the next time the clone is loaded.
+func (inst *instance) clone() *instance {
+	return &instance{
+		length:         inst.length,
+		snapshotted:    inst.snapshotted,
+		snapshot:       slices.Clone(inst.snapshot),
+		snapshotOffset: inst.snapshotOffset,
+	}
+}
+
+// state is a snapshot of the controller's state.
+type state struct {
+	instances map[string]*instance
+	histories map[string][]*envelope.Envelope
 }
 
 // Controller is an implementation of engine.Controller for
@@ -42,7 +80,36 @@ type Controller struct {
 	Config     *config.Aggregate
 	MessageIDs *envelope.MessageIDGenerator
 
+	// Events is the store used to persist the events recorded by each
+	// instance. If it is nil, events are kept in memory.
+	Events EventStore
+
+	// SnapshotInterval is the number of events that an instance must record
+	// after its most recent snapshot before another snapshot is taken. If it
+	// is zero, a snapshot is taken after every command that records an event.
+	// If it is negative, snapshots are never taken.
+	SnapshotInterval int
+
+	// VerifySnapshot returns true if an instance's snapshot is to be verified
+	// when the instance is loaded, by comparing a root that is built from the
+	// snapshot with one that has had the instance's entire history applied.
+	// If it is nil, snapshots are verified every time an instance is loaded.
+	VerifySnapshot func() bool
+
+	// m guards instances and the event store, which are accessed by multiple
+	// goroutines when messages for different instances are handled
+	// concurrently.
+	m         sync.Mutex
 	instances map[string]*instance
+	memory    memoryEventStore
+}
+
+// events returns the event store used by the controller.
+func (c *Controller) events() EventStore {
+	if c.Events != nil {
+		return c.Events
+	}
+	return &c.memory
 }
 
 // HandlerConfig returns the config of the handler that is managed by this
@@ -62,20 +129,34 @@ func (c *Controller) Tick(
 
 // Handle handles a message.
 func (c *Controller) Handle(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+) ([]*envelope.Envelope, error) {
+	return c.HandleRoute(ctx, obs, now, env, c.Route(ctx, env))
+}
+
+// HandleRoute handles a message that has already been routed to an instance
+// by Route().
+//
+// It is equivalent to Handle(), except that the handler's
+// RouteCommandToInstance() method is not called again.
+func (c *Controller) HandleRoute(
 	_ context.Context,
 	obs fact.Observer,
 	now time.Time,
 	env *envelope.Envelope,
+	r routing.Result,
 ) ([]*envelope.Envelope, error) {
-	mt := message.TypeOf(env.Message)
+	c.guardAgainstUnhandledMessage(env)
 
-	if !c.Config.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
-		panic(fmt.Sprintf("%s does not handle %s messages", c.Config.Identity(), mt))
+	id := r.InstanceID
+	inst, root, shadowRoot, err := c.instanceByID(obs, env, id)
+	if err != nil {
+		return nil, err
 	}
 
-	id := c.route(env, mt)
-	inst, root, shadowRoot := c.instanceByID(obs, env, id)
-
 	s := &scope{
 		instanceID: id,
 		config:     c.Config,
@@ -86,19 +167,7 @@ func (c *Controller) Handle(
 		shadowRoot: shadowRoot,
 		command:    env,
 		streamID:   uuidpb.Derive(c.Config.Identity().GetKey(), id).AsString(),
-		offset:     uint64(len(inst.history)),
-	}
-
-	if inst.snapshotted && !compare.Equal(root, shadowRoot) {
-		panic(panicx.UnexpectedBehavior{
-			Handler:        c.Config,
-			Interface:      "AggregateRoot",
-			Method:         "UnmarshalBinary",
-			Implementation: root,
-			Message:        env.Message,
-			Description:    "aggregate root state differs when built from events versus snapshot",
-			Location:       location.OfMethod(root, "UnmarshalBinary"),
-		})
+		offset:     uint64(inst.length),
 	}
 
 	panicx.EnrichUnexpectedMessage(
@@ -119,23 +188,250 @@ func (c *Controller) Handle(
 	s.guardAgainstDirectMutation("", location.Location{})
 
 	if len(s.events) != 0 {
-		if c.instances == nil {
-			c.instances = map[string]*instance{}
+		if err := c.appendEvents(id, inst, s.events); err != nil {
+			return nil, fmt.Errorf("unable to append events to the %q instance: %w", id, err)
+		}
+
+		inst.length += len(s.events)
+
+		if inst.copyRoot != nil {
+			c.applyEvents(inst.copyRoot, s.events)
 		}
 
-		c.instances[id] = inst
-		inst.history = append(inst.history, s.events...)
-		c.takeSnapshot(root, inst, env)
+		if c.isSnapshotDue(inst) {
+			c.takeSnapshot(root, inst, env)
+		}
 	}
 
+	inst.root = root
+	inst.shadowRoot = shadowRoot
+
 	return s.events, nil
 }
 
-// Reset clears the state of the controller.
+// Route returns the result of routing env to an instance, without notifying
+// any facts.
+//
+// Every command is routed to an instance. It panics if the handler's
+// RouteCommandToInstance() method returns an empty ID.
+func (c *Controller) Route(
+	_ context.Context,
+	env *envelope.Envelope,
+) routing.Result {
+	c.guardAgainstUnhandledMessage(env)
+
+	return routing.Result{
+		InstanceID: c.route(env, message.TypeOf(env.Message)),
+		OK:         true,
+	}
+}
+
+// guardAgainstUnhandledMessage panics if the handler does not handle messages
+// of the same type as env.
+func (c *Controller) guardAgainstUnhandledMessage(env *envelope.Envelope) {
+	mt := message.TypeOf(env.Message)
+
+	if !c.Config.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
+		panic(fmt.Sprintf("%s does not handle %s messages", c.Config.Identity(), mt))
+	}
+}
+
+// Seed appends events to the history of the instance with the given ID, as
+// though the instance had recorded them, and applies them to the instance's
+// roots.
+//
+// The handler's HandleCommand() method is not called, and the returned event
+// envelopes are not dispatched to any other handlers. events must not be empty.
+//
+// obs is notified of a fact.EventSeededIntoAggregate fact for each event.
+func (c *Controller) Seed(
+	obs fact.Observer,
+	now time.Time,
+	id string,
+	events []dogma.Event,
+) ([]*envelope.Envelope, error) {
+	c.m.Lock()
+	inst, ok := c.instances[id]
+	c.m.Unlock()
+
+	if !ok {
+		inst = &instance{}
+	}
+
+	streamID := uuidpb.Derive(c.Config.Identity().GetKey(), id).AsString()
+	envs := make([]*envelope.Envelope, len(events))
+
+	for i, m := range events {
+		env := envelope.NewEvent(c.MessageIDs.Next(), m, now)
+		env.Origin = &envelope.Origin{
+			Handler:     c.Config,
+			HandlerType: config.AggregateHandlerType,
+			InstanceID:  id,
+		}
+		env.EventStreamID = streamID
+		env.EventStreamOffset = uint64(inst.length + i)
+		envs[i] = env
+	}
+
+	root, shadowRoot := inst.root, inst.shadowRoot
+	inst.root, inst.shadowRoot = nil, nil
+
+	if !ok {
+		root, shadowRoot = c.newRoots(envs[0])
+	} else if root == nil {
+		inst.copyRoot = nil
+
+		var err error
+		root, shadowRoot, err = c.rebuildRoots(envs[0], id, inst, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, env := range envs {
+		c.applyEvents(root, envs[i:i+1])
+
+		obs.Notify(fact.EventSeededIntoAggregate{
+			Handler:       c.Config,
+			InstanceID:    id,
+			Root:          root,
+			EventEnvelope: env,
+		})
+	}
+
+	c.applyEvents(shadowRoot, envs)
+
+	if inst.copyRoot != nil {
+		c.applyEvents(inst.copyRoot, envs)
+	}
+
+	if err := c.appendEvents(id, inst, envs); err != nil {
+		return nil, fmt.Errorf("unable to append events to the %q instance: %w", id, err)
+	}
+
+	inst.length += len(envs)
+
+	if c.isSnapshotDue(inst) {
+		c.takeSnapshot(root, inst, envs[len(envs)-1])
+	}
+
+	inst.root = root
+	inst.shadowRoot = shadowRoot
+
+	return envs, nil
+}
+
+// appendEvents appends events to the history of the given instance, and adds
+// the instance to the controller if it is new.
+func (c *Controller) appendEvents(
+	id string,
+	inst *instance,
+	events []*envelope.Envelope,
+) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if err := c.events().AppendEvents(c.Config, id, events); err != nil {
+		return err
+	}
+
+	if c.instances == nil {
+		c.instances = map[string]*instance{}
+	}
+
+	c.instances[id] = inst
+
+	return nil
+}
+
+// Reset clears the state of the controller, including the events in its event
+// store.
+//
+// It panics if the events can not be removed from the event store.
 func (c *Controller) Reset() {
+	for id := range c.instances {
+		c.deleteEvents(id)
+	}
+
 	c.instances = nil
 }
 
+// Snapshot returns an opaque representation of the controller's state.
+//
+// It panics if the events can not be loaded from the event store.
+func (c *Controller) Snapshot() any {
+	s := state{
+		instances: make(map[string]*instance, len(c.instances)),
+		histories: make(map[string][]*envelope.Envelope, len(c.instances)),
+	}
+
+	for id, inst := range c.instances {
+		s.instances[id] = inst.clone()
+		s.histories[id] = c.loadEvents(id)
+	}
+
+	return s
+}
+
+// Restore replaces the controller's state with a snapshot previously returned
+// by Snapshot().
+//
+// It panics if the event store can not be updated to match the snapshot.
+func (c *Controller) Restore(snapshot any) {
+	s := snapshot.(state)
+
+	for id := range c.instances {
+		c.deleteEvents(id)
+	}
+
+	c.instances = make(map[string]*instance, len(s.instances))
+
+	for id, inst := range s.instances {
+		if err := c.events().AppendEvents(c.Config, id, s.histories[id]); err != nil {
+			panic(fmt.Sprintf("unable to restore the events of the %q instance: %s", id, err))
+		}
+
+		c.instances[id] = inst.clone()
+	}
+}
+
+// InstanceIDs returns the IDs of all instances that have recorded at least one
+// event, in lexical order.
+func (c *Controller) InstanceIDs() []string {
+	ids := slices.Collect(maps.Keys(c.instances))
+	slices.Sort(ids)
+	return ids
+}
+
+// Root returns the aggregate root of the instance with the given ID, rebuilt by
+// applying the instance's entire history to a new root.
+//
+// ok is false if the instance has not recorded any events.
+//
+// It panics if the events can not be loaded from the event store.
+func (c *Controller) Root(id string) (dogma.AggregateRoot, bool) {
+	if _, ok := c.instances[id]; !ok {
+		return nil, false
+	}
+
+	root := c.Config.Source.Get().New()
+
+	if xreflect.IsNil(root) {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "AggregateMessageHandler",
+			Method:         "New",
+			Implementation: c.Config.Implementation(),
+			Description:    "returned a nil aggregate root",
+			Location:       location.OfMethod(c.Config.Implementation(), "New"),
+		})
+	}
+
+	c.applyEvents(root, c.loadEvents(id))
+
+	return root, true
+}
+
 // route returns the instance ID that the command should be routed to.
 func (c *Controller) route(env *envelope.Envelope, mt message.Type) string {
 	var id string
@@ -168,17 +464,80 @@ func (c *Controller) route(env *envelope.Envelope, mt message.Type) string {
 }
 
 // instanceByID returns the instance, root, and shadow root for the given
-// instance ID. The shadow root is built by replaying the full event history
-// from New(), ignoring any snapshot.
+// instance ID.
+//
+// If the instance's roots were kept after the last command it handled, they
+// are reused as-is. Otherwise, they are rebuilt from the instance's snapshot
+// and history.
 func (c *Controller) instanceByID(
 	obs fact.Observer,
 	env *envelope.Envelope,
 	id string,
-) (inst *instance, root, shadowRoot dogma.AggregateRoot) {
-	root = c.Config.Source.Get().New()
-	shadowRoot = c.Config.Source.Get().New()
+) (inst *instance, root, shadowRoot dogma.AggregateRoot, err error) {
+	c.m.Lock()
+	inst, ok := c.instances[id]
+	c.m.Unlock()
+
+	if !ok {
+		root, shadowRoot = c.newRoots(env)
+
+		obs.Notify(fact.AggregateInstanceNotFound{
+			Handler:    c.Config,
+			InstanceID: id,
+			Envelope:   env,
+		})
+
+		inst = &instance{}
+
+		return inst, root, shadowRoot, nil
+	}
+
+	root, shadowRoot = inst.root, inst.shadowRoot
+	inst.root, inst.shadowRoot = nil, nil
+
+	verify := inst.snapshotted &&
+		(c.VerifySnapshot == nil || c.VerifySnapshot())
+
+	if root == nil {
+		inst.copyRoot = nil
+		root, shadowRoot, err = c.rebuildRoots(env, id, inst, verify)
+	} else if verify {
+		err = c.verifySnapshot(env, id, inst, shadowRoot)
+	}
 
-	if xreflect.IsNil(root) || xreflect.IsNil(shadowRoot) {
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The fact is given a copy of the root, as the root itself is modified by
+	// this and any subsequent commands.
+	cp, err := c.rootForFact(env, id, inst, root)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	obs.Notify(fact.AggregateInstanceLoaded{
+		Handler:        c.Config,
+		InstanceID:     id,
+		Root:           cp,
+		Envelope:       env,
+		SnapshotOffset: inst.snapshotOffset,
+	})
+
+	return inst, root, shadowRoot, nil
+}
+
+// newRoots returns two new aggregate roots, for use as an instance's root and
+// shadow root.
+func (c *Controller) newRoots(env *envelope.Envelope) (root, shadowRoot dogma.AggregateRoot) {
+	return c.newRoot(env), c.newRoot(env)
+}
+
+// newRoot returns a new aggregate root.
+func (c *Controller) newRoot(env *envelope.Envelope) dogma.AggregateRoot {
+	r := c.Config.Source.Get().New()
+
+	if xreflect.IsNil(r) {
 		panic(panicx.UnexpectedBehavior{
 			Handler:        c.Config,
 			Interface:      "AggregateMessageHandler",
@@ -190,68 +549,229 @@ func (c *Controller) instanceByID(
 		})
 	}
 
-	inst, ok := c.instances[id]
-	if !ok {
-		obs.Notify(fact.AggregateInstanceNotFound{
-			Handler:    c.Config,
-			InstanceID: id,
-			Envelope:   env,
+	return r
+}
+
+// rootForFact returns a copy of r, the root of the instance with the given ID,
+// for use in an AggregateInstanceLoaded fact.
+//
+// The copy is made by marshaling r and unmarshaling the data into a new root.
+// If r does not support marshaling, the instance's copyRoot is returned
+// instead, building it from the instance's history the first time it is
+// needed. It is then kept up to date by applying each new event, rather than
+// being rebuilt from the entire history each time the instance is loaded.
+func (c *Controller) rootForFact(
+	env *envelope.Envelope,
+	id string,
+	inst *instance,
+	r dogma.AggregateRoot,
+) (dogma.AggregateRoot, error) {
+	if inst.copyRoot != nil {
+		return inst.copyRoot, nil
+	}
+
+	cp := c.newRoot(env)
+
+	data, err := r.MarshalBinary()
+	if err == nil {
+		if err := cp.UnmarshalBinary(data); err != nil {
+			panic(panicx.UnexpectedBehavior{
+				Handler:        c.Config,
+				Interface:      "AggregateRoot",
+				Method:         "UnmarshalBinary",
+				Implementation: cp,
+				Message:        env.Message,
+				Description:    fmt.Sprintf("unable to unmarshal the aggregate root: %s", err),
+				Location:       location.OfMethod(cp, "UnmarshalBinary"),
+			})
+		}
+
+		return cp, nil
+	}
+
+	if !errors.Is(err, dogma.ErrNotSupported) {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "AggregateRoot",
+			Method:         "MarshalBinary",
+			Implementation: r,
+			Message:        env.Message,
+			Description:    fmt.Sprintf("unable to marshal the aggregate root: %s", err),
+			Location:       location.OfMethod(r, "MarshalBinary"),
 		})
+	}
 
-		inst = &instance{}
+	c.m.Lock()
+	history, err := c.events().LoadEvents(c.Config, id)
+	c.m.Unlock()
 
-		return inst, root, shadowRoot
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the events of the %q instance: %w", id, err)
 	}
 
-	for _, ev := range inst.history {
-		panicx.EnrichUnexpectedMessage(
-			c.Config,
-			"AggregateRoot",
-			"ApplyEvent",
-			shadowRoot,
-			ev.Message,
-			func() {
-				shadowRoot.ApplyEvent(ev.Message.(dogma.Event))
-			},
-		)
+	c.loadRoot(cp, inst, history[inst.snapshotOffset:], env)
+	inst.copyRoot = cp
+
+	return cp, nil
+}
+
+// rebuildRoots rebuilds an instance's root and shadow root from its snapshot
+// and history.
+//
+// If verify is true, the shadow root is built by replaying the full event
+// history from New(), ignoring the snapshot, and is compared to the root.
+// Otherwise, it is built in the same way as the root.
+func (c *Controller) rebuildRoots(
+	env *envelope.Envelope,
+	id string,
+	inst *instance,
+	verify bool,
+) (root, shadowRoot dogma.AggregateRoot, err error) {
+	root, shadowRoot = c.newRoots(env)
+
+	c.m.Lock()
+	history, err := c.events().LoadEvents(c.Config, id)
+	c.m.Unlock()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load the events of the %q instance: %w", id, err)
+	}
+
+	tail := history[inst.snapshotOffset:]
+	c.loadRoot(root, inst, tail, env)
+
+	if verify {
+		c.applyEvents(shadowRoot, history)
+		c.compareRoots(env, root, shadowRoot)
+	} else {
+		c.loadRoot(shadowRoot, inst, tail, env)
+	}
+
+	return root, shadowRoot, nil
+}
+
+// verifySnapshot builds a root from the instance's snapshot and the events
+// recorded after it, and compares it to shadowRoot, which has had the
+// instance's entire history applied.
+func (c *Controller) verifySnapshot(
+	env *envelope.Envelope,
+	id string,
+	inst *instance,
+	shadowRoot dogma.AggregateRoot,
+) error {
+	root, _ := c.newRoots(env)
+
+	var tail []*envelope.Envelope
+
+	if inst.snapshotOffset < inst.length {
+		c.m.Lock()
+		history, err := c.events().LoadEvents(c.Config, id)
+		c.m.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("unable to load the events of the %q instance: %w", id, err)
+		}
+
+		tail = history[inst.snapshotOffset:]
+	}
+
+	c.loadRoot(root, inst, tail, env)
+	c.compareRoots(env, root, shadowRoot)
+
+	return nil
+}
+
+// compareRoots panics if root, which was built from a snapshot, differs from
+// shadowRoot, which was built from the instance's entire history.
+func (c *Controller) compareRoots(
+	env *envelope.Envelope,
+	root, shadowRoot dogma.AggregateRoot,
+) {
+	if !compare.Equal(root, shadowRoot) {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "AggregateRoot",
+			Method:         "UnmarshalBinary",
+			Implementation: root,
+			Message:        env.Message,
+			Description:    "aggregate root state differs when built from events versus snapshot",
+			Location:       location.OfMethod(root, "UnmarshalBinary"),
+		})
 	}
+}
 
+// loadRoot populates r from the instance's snapshot, if it has one, then
+// applies tail, the events that were recorded after the snapshot was taken.
+func (c *Controller) loadRoot(
+	r dogma.AggregateRoot,
+	inst *instance,
+	tail []*envelope.Envelope,
+	env *envelope.Envelope,
+) {
 	if inst.snapshotted {
-		if err := root.UnmarshalBinary(inst.snapshot); err != nil {
+		if err := r.UnmarshalBinary(inst.snapshot); err != nil {
 			panic(panicx.UnexpectedBehavior{
 				Handler:        c.Config,
 				Interface:      "AggregateRoot",
 				Method:         "UnmarshalBinary",
-				Implementation: root,
+				Implementation: r,
 				Message:        env.Message,
 				Description:    fmt.Sprintf("unable to unmarshal the aggregate root: %s", err),
-				Location:       location.OfMethod(root, "UnmarshalBinary"),
+				Location:       location.OfMethod(r, "UnmarshalBinary"),
 			})
 		}
 	}
 
-	for _, ev := range inst.history[inst.snapshotOffset:] {
+	c.applyEvents(r, tail)
+}
+
+// applyEvents applies each of the given events to r.
+func (c *Controller) applyEvents(
+	r dogma.AggregateRoot,
+	events []*envelope.Envelope,
+) {
+	for _, ev := range events {
 		panicx.EnrichUnexpectedMessage(
 			c.Config,
 			"AggregateRoot",
 			"ApplyEvent",
-			root,
+			r,
 			ev.Message,
 			func() {
-				root.ApplyEvent(ev.Message.(dogma.Event))
+				r.ApplyEvent(ev.Message.(dogma.Event))
 			},
 		)
 	}
+}
 
-	obs.Notify(fact.AggregateInstanceLoaded{
-		Handler:        c.Config,
-		InstanceID:     id,
-		Root:           root,
-		Envelope:       env,
-		SnapshotOffset: inst.snapshotOffset,
-	})
+// loadEvents returns the history of the instance with the given ID.
+//
+// It panics if the events can not be loaded from the event store.
+func (c *Controller) loadEvents(id string) []*envelope.Envelope {
+	history, err := c.events().LoadEvents(c.Config, id)
+	if err != nil {
+		panic(fmt.Sprintf("unable to load the events of the %q instance: %s", id, err))
+	}
+	return history
+}
+
+// deleteEvents removes the history of the instance with the given ID.
+//
+// It panics if the events can not be removed from the event store.
+func (c *Controller) deleteEvents(id string) {
+	if err := c.events().DeleteEvents(c.Config, id); err != nil {
+		panic(fmt.Sprintf("unable to delete the events of the %q instance: %s", id, err))
+	}
+}
+
+// isSnapshotDue returns true if a snapshot of the instance's root is to be
+// taken, according to the controller's snapshot interval.
+func (c *Controller) isSnapshotDue(inst *instance) bool {
+	if c.SnapshotInterval < 0 {
+		return false
+	}
 
-	return inst, root, shadowRoot
+	return inst.length-inst.snapshotOffset >= max(c.SnapshotInterval, 1)
 }
 
 // takeSnapshot attempts to store a snapshot of the aggregate root.
@@ -279,5 +799,5 @@ func (c *Controller) takeSnapshot(
 
 	inst.snapshotted = true
 	inst.snapshot = data
-	inst.snapshotOffset = len(inst.history)
+	inst.snapshotOffset = inst.length
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/dogmatiq/enginekit/enginetest/stubs"
 	"github.com/dogmatiq/testkit/engine/internal/aggregate"
 	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/engine/internal/routing"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
 	"github.com/dogmatiq/testkit/internal/x/xtesting"
@@ -281,6 +282,7 @@ func TestControllerHandle(t *testing.T) {
 	t.Run("panics if New returns nil when the instance exists", func(t *testing.T) {
 		f := newControllerTestFixture()
 		seedControllerInstance(t, f)
+		evictRoots(f)
 
 		f.handler.NewFunc = func() *stubs.AggregateRootStub {
 			return nil
@@ -461,6 +463,7 @@ func TestControllerHandle(t *testing.T) {
 		}
 
 		seedControllerInstance(t, f)
+		evictRoots(f)
 
 		f.handler.NewFunc = func() *stubs.AggregateRootStub {
 			return &stubs.AggregateRootStub{
@@ -547,6 +550,7 @@ func TestControllerHandle(t *testing.T) {
 	t.Run("panics if UnmarshalBinary fails when loading a snapshot", func(t *testing.T) {
 		f := newControllerTestFixture()
 		seedControllerInstance(t, f)
+		evictRoots(f)
 
 		f.handler.NewFunc = func() *stubs.AggregateRootStub {
 			return &stubs.AggregateRootStub{
@@ -608,6 +612,8 @@ func TestControllerHandle(t *testing.T) {
 					t.Fatal(err)
 				}
 
+				evictRoots(f)
+
 				called := false
 				f.handler.NewFunc = func() *stubs.AggregateRootStub {
 					r := &stubs.AggregateRootStub{}
@@ -639,6 +645,91 @@ func TestControllerHandle(t *testing.T) {
 	})
 }
 
+func TestControllerEvents(t *testing.T) {
+	t.Run("returns an error if the events can not be appended", func(t *testing.T) {
+		f := newControllerTestFixture()
+		f.ctrl.Events = &eventStoreStub{
+			appendErr: errors.New("<error>"),
+		}
+
+		f.handler.HandleCommandFunc = func(
+			_ *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		_, err := f.ctrl.Handle(
+			context.Background(),
+			fact.Ignore,
+			time.Now(),
+			f.command,
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected error",
+			fmt.Sprint(err),
+			`unable to append events to the "<instance-A1>" instance: <error>`,
+		)
+	})
+
+	t.Run("returns an error if the events can not be loaded", func(t *testing.T) {
+		f := newControllerTestFixture()
+		store := &eventStoreStub{}
+		f.ctrl.Events = store
+		seedControllerInstance(t, f)
+		evictRoots(f)
+
+		store.loadErr = errors.New("<error>")
+
+		_, err := f.ctrl.Handle(
+			context.Background(),
+			fact.Ignore,
+			time.Now(),
+			f.command,
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected error",
+			fmt.Sprint(err),
+			`unable to load the events of the "<instance-A1>" instance: <error>`,
+		)
+	})
+}
+
+// eventStoreStub is an in-memory aggregate.EventStore that can be configured
+// to fail.
+type eventStoreStub struct {
+	streams   map[string][]*envelope.Envelope
+	appendErr error
+	loadErr   error
+}
+
+func (s *eventStoreStub) AppendEvents(_ *config.Aggregate, id string, events []*envelope.Envelope) error {
+	if s.appendErr != nil {
+		return s.appendErr
+	}
+
+	if s.streams == nil {
+		s.streams = map[string][]*envelope.Envelope{}
+	}
+
+	s.streams[id] = append(s.streams[id], events...)
+	return nil
+}
+
+func (s *eventStoreStub) LoadEvents(_ *config.Aggregate, id string) ([]*envelope.Envelope, error) {
+	return s.streams[id], s.loadErr
+}
+
+func (s *eventStoreStub) DeleteEvents(_ *config.Aggregate, id string) error {
+	delete(s.streams, id)
+	return nil
+}
+
 func TestControllerReset(t *testing.T) {
 	f := newControllerTestFixture()
 	seedControllerInstance(t, f)
@@ -660,6 +751,133 @@ func TestControllerReset(t *testing.T) {
 	}
 }
 
+func TestControllerInstanceIDs(t *testing.T) {
+	t.Run("returns the IDs of instances that have recorded events", func(t *testing.T) {
+		f := newControllerTestFixture()
+		seedControllerInstance(t, f)
+
+		xtesting.Expect(
+			t,
+			"unexpected instance IDs",
+			f.ctrl.InstanceIDs(),
+			[]string{"<instance-A1>"},
+		)
+	})
+
+	t.Run("does not include instances that have not recorded any events", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		_, err := f.ctrl.Handle(
+			context.Background(),
+			fact.Ignore,
+			time.Now(),
+			f.command,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected instance IDs", f.ctrl.InstanceIDs(), []string{})
+	})
+}
+
+func TestControllerRoute(t *testing.T) {
+	t.Run("returns the ID of the instance that a command is routed to", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		xtesting.Expect(
+			t,
+			"unexpected result",
+			f.ctrl.Route(context.Background(), f.command),
+			routing.Result{InstanceID: "<instance-A1>", OK: true},
+		)
+	})
+}
+
+func TestControllerHandleRoute(t *testing.T) {
+	t.Run("handles the command without routing it again", func(t *testing.T) {
+		f := newControllerTestFixture()
+		r := f.ctrl.Route(context.Background(), f.command)
+
+		f.handler.RouteCommandToInstanceFunc = func(dogma.Command) string {
+			t.Fatal("unexpected call to RouteCommandToInstance()")
+			return ""
+		}
+
+		f.handler.HandleCommandFunc = func(
+			_ *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		if _, err := f.ctrl.HandleRoute(
+			context.Background(),
+			fact.Ignore,
+			time.Now(),
+			f.command,
+			r,
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected instance IDs", f.ctrl.InstanceIDs(), []string{"<instance-A1>"})
+	})
+}
+
+func TestControllerRoot(t *testing.T) {
+	t.Run("returns a root with all historical events applied", func(t *testing.T) {
+		f := newControllerTestFixture()
+		seedControllerInstance(t, f)
+		seedControllerInstance(t, f)
+
+		r, ok := f.ctrl.Root("<instance-A1>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&stubs.AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					stubs.EventA1,
+					stubs.EventA1,
+				},
+			}),
+		)
+	})
+
+	t.Run("returns false if the instance does not exist", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		if _, ok := f.ctrl.Root("<instance-A1>"); ok {
+			t.Fatal("did not expect instance to exist")
+		}
+	})
+
+	t.Run("panics if New returns nil", func(t *testing.T) {
+		f := newControllerTestFixture()
+		seedControllerInstance(t, f)
+
+		f.handler.NewFunc = func() *stubs.AggregateRootStub {
+			return nil
+		}
+
+		xtesting.ExpectPanicMatching(
+			t,
+			func() {
+				f.ctrl.Root("<instance-A1>")
+			},
+			func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected description", x.Description, "returned a nil aggregate root")
+			},
+		)
+	})
+}
+
 type controllerTestFixture struct {
 	messageIDs envelope.MessageIDGenerator
 	handler    *stubs.AggregateMessageHandlerStub[*stubs.AggregateRootStub]
@@ -730,6 +948,13 @@ func seedControllerInstance(t *testing.T, f *controllerTestFixture) {
 	f.handler.HandleCommandFunc = nil
 }
 
+// evictRoots discards the aggregate roots that the controller keeps in memory,
+// such that they are rebuilt from their snapshots and histories the next time
+// each instance is loaded.
+func evictRoots(f *controllerTestFixture) {
+	f.ctrl.Restore(f.ctrl.Snapshot())
+}
+
 func findFact[T any](facts []fact.Fact) (T, bool) {
 	var zero T
 
@@ -855,6 +1080,7 @@ func TestStaleSnapshot(t *testing.T) {
 		}
 		f.cfg = runtimeconfig.FromAggregate(f.handler)
 		f.ctrl.Config = f.cfg
+		evictRoots(f)
 
 		// Second command: records another event. MarshalBinary returns
 		// ErrNotSupported, so snapshotOffset stays at 1 while history
@@ -898,3 +1124,246 @@ func TestStaleSnapshot(t *testing.T) {
 		}
 	})
 }
+
+func TestRootCache(t *testing.T) {
+	t.Run("reuses the aggregate root between commands", func(t *testing.T) {
+		f := newControllerTestFixture()
+		f.ctrl.VerifySnapshot = func() bool { return false }
+
+		var (
+			created int
+			roots   []*stubs.AggregateRootStub
+		)
+
+		f.handler.NewFunc = func() *stubs.AggregateRootStub {
+			created++
+			return &stubs.AggregateRootStub{}
+		}
+
+		f.handler.HandleCommandFunc = func(
+			r *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			roots = append(roots, r)
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		for range 3 {
+			if _, err := f.ctrl.Handle(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.command,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The root and shadow root are created once, and another root is
+		// created each time the instance is loaded, as a copy of the root for
+		// the AggregateInstanceLoaded fact.
+		xtesting.Expect(t, "unexpected number of roots created", created, 4)
+
+		if roots[1] != roots[0] || roots[2] != roots[0] {
+			t.Fatal("expected the same root to be passed to each call to HandleCommand")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected events applied to the root",
+			roots[0].AppliedEvents,
+			[]dogma.Event{stubs.EventA1, stubs.EventA1, stubs.EventA1},
+		)
+	})
+
+	t.Run("records a copy of the aggregate root in the AggregateInstanceLoaded fact", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		f.handler.HandleCommandFunc = func(
+			_ *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		var loaded []fact.AggregateInstanceLoaded
+		obs := fact.ObserverFunc(func(x fact.Fact) {
+			if x, ok := x.(fact.AggregateInstanceLoaded); ok {
+				loaded = append(loaded, x)
+			}
+		})
+
+		for range 3 {
+			if _, err := f.ctrl.Handle(
+				context.Background(),
+				obs,
+				time.Now(),
+				f.command,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root in the first fact",
+			loaded[0].Root,
+			dogma.AggregateRoot(&stubs.AggregateRootStub{
+				AppliedEvents: []dogma.Event{stubs.EventA1},
+			}),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected root in the second fact",
+			loaded[1].Root,
+			dogma.AggregateRoot(&stubs.AggregateRootStub{
+				AppliedEvents: []dogma.Event{stubs.EventA1, stubs.EventA1},
+			}),
+		)
+	})
+
+	t.Run("keeps a separate root up to date for the AggregateInstanceLoaded fact if MarshalBinary returns ErrNotSupported", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		f.handler.NewFunc = func() *stubs.AggregateRootStub {
+			return &stubs.AggregateRootStub{
+				MarshalBinaryFunc: func() ([]byte, error) {
+					return nil, dogma.ErrNotSupported
+				},
+			}
+		}
+
+		var roots []dogma.AggregateRoot
+		f.handler.HandleCommandFunc = func(
+			r *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			roots = append(roots, r)
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		var loaded []fact.AggregateInstanceLoaded
+		obs := fact.ObserverFunc(func(x fact.Fact) {
+			if x, ok := x.(fact.AggregateInstanceLoaded); ok {
+				xtesting.Expect(
+					t,
+					"unexpected number of events applied to the root",
+					len(x.Root.(*stubs.AggregateRootStub).AppliedEvents),
+					len(loaded)+1,
+				)
+				loaded = append(loaded, x)
+			}
+		})
+
+		for range 3 {
+			if _, err := f.ctrl.Handle(
+				context.Background(),
+				obs,
+				time.Now(),
+				f.command,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, x := range loaded {
+			if x.Root == roots[0] {
+				t.Fatal("expected the fact to contain a root other than the one passed to HandleCommand")
+			}
+		}
+	})
+
+	t.Run("applies each event a constant number of times if MarshalBinary returns ErrNotSupported", func(t *testing.T) {
+		f := newControllerTestFixture()
+
+		var applied int
+		f.handler.NewFunc = func() *stubs.AggregateRootStub {
+			return &stubs.AggregateRootStub{
+				ApplyEventFunc: func(dogma.Event) {
+					applied++
+				},
+				MarshalBinaryFunc: func() ([]byte, error) {
+					return nil, dogma.ErrNotSupported
+				},
+			}
+		}
+
+		f.handler.HandleCommandFunc = func(
+			_ *stubs.AggregateRootStub,
+			s dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(stubs.EventA1)
+		}
+
+		const n = 100
+
+		for range n {
+			if _, err := f.ctrl.Handle(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.command,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Each event is applied to the root, the shadow root and the root
+		// that is given to the AggregateInstanceLoaded fact.
+		xtesting.Expect(t, "unexpected number of calls to ApplyEvent()", applied, 3*n)
+	})
+
+	t.Run("rebuilds the aggregate root if the handler panics", func(t *testing.T) {
+		f := newControllerTestFixture()
+		f.ctrl.VerifySnapshot = func() bool { return false }
+		seedControllerInstance(t, f)
+
+		f.handler.HandleCommandFunc = func(
+			r *stubs.AggregateRootStub,
+			_ dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			r.AppliedEvents = append(r.AppliedEvents, stubs.EventA2)
+			panic("<panic>")
+		}
+
+		xtesting.ExpectPanic(t, "<panic>", func() {
+			_, _ = f.ctrl.Handle(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.command,
+			)
+		})
+
+		var root *stubs.AggregateRootStub
+		f.handler.HandleCommandFunc = func(
+			r *stubs.AggregateRootStub,
+			_ dogma.AggregateCommandScope[*stubs.AggregateRootStub],
+			_ dogma.Command,
+		) {
+			root = r
+		}
+
+		if _, err := f.ctrl.Handle(
+			context.Background(),
+			fact.Ignore,
+			time.Now(),
+			f.command,
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected events applied to the root",
+			root.AppliedEvents,
+			[]dogma.Event{stubs.EventA1},
+		)
+	})
+}
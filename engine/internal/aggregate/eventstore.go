@@ -0,0 +1,57 @@
+package aggregate
+
+import (
+	"slices"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// EventStore persists the events recorded by aggregate instances.
+//
+// It is structurally identical to engine.EventStore, which can not be
+// referenced from this package without introducing an import cycle.
+type EventStore interface {
+	// AppendEvents appends events to the history of an aggregate instance.
+	AppendEvents(h *config.Aggregate, id string, events []*envelope.Envelope) error
+
+	// LoadEvents returns the entire history of an aggregate instance.
+	LoadEvents(h *config.Aggregate, id string) ([]*envelope.Envelope, error)
+
+	// DeleteEvents removes the entire history of an aggregate instance.
+	DeleteEvents(h *config.Aggregate, id string) error
+}
+
+// memoryEventStore is an in-memory implementation of EventStore that stores
+// the events of a single aggregate handler.
+type memoryEventStore struct {
+	streams map[string][]*envelope.Envelope
+}
+
+func (s *memoryEventStore) AppendEvents(
+	_ *config.Aggregate,
+	id string,
+	events []*envelope.Envelope,
+) error {
+	if s.streams == nil {
+		s.streams = map[string][]*envelope.Envelope{}
+	}
+
+	s.streams[id] = append(s.streams[id], events...)
+	return nil
+}
+
+func (s *memoryEventStore) LoadEvents(
+	_ *config.Aggregate,
+	id string,
+) ([]*envelope.Envelope, error) {
+	return slices.Clone(s.streams[id]), nil
+}
+
+func (s *memoryEventStore) DeleteEvents(
+	_ *config.Aggregate,
+	id string,
+) error {
+	delete(s.streams, id)
+	return nil
+}
@@ -86,3 +86,14 @@ func (c *Controller) Handle(
 // Reset does nothing.
 func (c *Controller) Reset() {
 }
+
+// Snapshot returns an opaque representation of the controller's state.
+func (c *Controller) Snapshot() any {
+	return c.offset
+}
+
+// Restore replaces the controller's state with a snapshot previously returned
+// by Snapshot().
+func (c *Controller) Restore(snapshot any) {
+	c.offset = snapshot.(uint64)
+}
@@ -0,0 +1,35 @@
+package panicx
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Recovered is a panic value that carries a value recovered from a panic on
+// one goroutine so that it can be re-raised on another.
+//
+// Re-raising the original value would discard the stack of the goroutine that
+// panicked, so Recovered captures it.
+type Recovered struct {
+	// Value is the value that was passed to panic().
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked, as returned by
+	// debug.Stack().
+	Stack []byte
+}
+
+// Recover returns a Recovered panic value for v, capturing the stack of the
+// calling goroutine.
+//
+// It must be called from the deferred function that recovered v.
+func Recover(v any) Recovered {
+	return Recovered{
+		Value: v,
+		Stack: debug.Stack(),
+	}
+}
+
+func (x Recovered) String() string {
+	return fmt.Sprintf("%v\n\ngoroutine stack at time of panic:\n%s", x.Value, x.Stack)
+}
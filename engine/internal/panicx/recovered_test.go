@@ -0,0 +1,46 @@
+package panicx_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestRecovered(t *testing.T) {
+	t.Run("func Recover()", func(t *testing.T) {
+		t.Run("it captures the stack of the goroutine that panicked", func(t *testing.T) {
+			var x Recovered
+
+			func() {
+				defer func() {
+					x = Recover(recover())
+				}()
+
+				panic("<panic>")
+			}()
+
+			xtesting.Expect(t, "unexpected value", x.Value, any("<panic>"))
+
+			if !strings.Contains(string(x.Stack), "TestRecovered") {
+				t.Fatalf("expected stack to include the panicking function, got:\n%s", x.Stack)
+			}
+		})
+	})
+
+	t.Run("func String()", func(t *testing.T) {
+		x := Recovered{
+			Value: "<panic>",
+			Stack: []byte("<stack>"),
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected string representation",
+			x.String(),
+			"<panic>\n\ngoroutine stack at time of panic:\n<stack>",
+		)
+	})
+
+}
@@ -1,18 +1,25 @@
 package process
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/dogmatiq/dapper"
 	"github.com/dogmatiq/dogma"
 	"github.com/dogmatiq/enginekit/config"
 	"github.com/dogmatiq/enginekit/message"
 	"github.com/dogmatiq/testkit/engine/internal/panicx"
+	"github.com/dogmatiq/testkit/engine/internal/routing"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/compare"
 	"github.com/dogmatiq/testkit/internal/x/xreflect"
 	"github.com/dogmatiq/testkit/location"
 )
@@ -29,12 +36,49 @@ type instance struct {
 	ended bool
 }
 
+// clone returns a deep copy of the instance.
+func (inst *instance) clone() *instance {
+	return &instance{
+		mutated: inst.mutated,
+		data:    slices.Clone(inst.data),
+		ended:   inst.ended,
+	}
+}
+
+// state is the representation of the controller's state returned by
+// Snapshot().
+type state struct {
+	instances map[string]*instance
+	deadlines []*envelope.Envelope
+}
+
+// clone returns a deep copy of the state.
+func (s state) clone() state {
+	var instances map[string]*instance
+
+	if s.instances != nil {
+		instances = make(map[string]*instance, len(s.instances))
+		for id, inst := range s.instances {
+			instances[id] = inst.clone()
+		}
+	}
+
+	return state{
+		instances: instances,
+		deadlines: slices.Clone(s.deadlines),
+	}
+}
+
 // Controller is an implementation of engine.Controller for
 // dogma.ProcessMessageHandler implementations.
 type Controller struct {
 	Config     *config.Process
 	MessageIDs *envelope.MessageIDGenerator
 
+	// m guards instances and deadlines, which are accessed by multiple
+	// goroutines when messages for different instances are handled
+	// concurrently.
+	m         sync.Mutex
 	instances map[string]*instance
 	deadlines []*envelope.Envelope
 }
@@ -78,13 +122,24 @@ func (c *Controller) Handle(
 	now time.Time,
 	env *envelope.Envelope,
 ) ([]*envelope.Envelope, error) {
-	mt := message.TypeOf(env.Message)
+	return c.HandleRoute(ctx, obs, now, env, c.Route(ctx, env))
+}
 
-	if !c.Config.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
-		panic(fmt.Sprintf("%s does not handle %s messages", c.Config.Identity(), mt))
-	}
+// HandleRoute handles a message that has already been routed to an instance
+// by Route().
+//
+// It is equivalent to Handle(), except that the handler's
+// RouteEventToInstance() method is not called again.
+func (c *Controller) HandleRoute(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	r routing.Result,
+) ([]*envelope.Envelope, error) {
+	c.guardAgainstUnhandledMessage(env)
 
-	id, ok, err := c.route(ctx, obs, env)
+	id, ok, err := c.route(obs, env, r)
 	if !ok || err != nil {
 		return nil, err
 	}
@@ -135,6 +190,9 @@ func (c *Controller) Handle(
 				Location:       location.OfMethod(s.root, "MarshalBinary"),
 			})
 		}
+
+		c.verifyRoundTrip(s.root, data, env)
+
 		inst.mutated = true
 		inst.data = data
 	}
@@ -163,20 +221,24 @@ func (c *Controller) instanceByID(
 		})
 	}
 
+	c.m.Lock()
 	inst, ok := c.instances[id]
 	if !ok {
-		obs.Notify(fact.ProcessInstanceNotFound{
-			Handler:    c.Config,
-			InstanceID: id,
-			Envelope:   env,
-		})
-
 		if c.instances == nil {
 			c.instances = map[string]*instance{}
 		}
 
 		inst = &instance{}
 		c.instances[id] = inst
+	}
+	c.m.Unlock()
+
+	if !ok {
+		obs.Notify(fact.ProcessInstanceNotFound{
+			Handler:    c.Config,
+			InstanceID: id,
+			Envelope:   env,
+		})
 
 		obs.Notify(fact.ProcessInstanceBegun{
 			Handler:    c.Config,
@@ -224,56 +286,298 @@ func (c *Controller) instanceByID(
 	return inst, root, shadowRoot
 }
 
+// verifyRoundTrip panics if r is not preserved when data, the result of
+// marshaling r, is unmarshaled into a new process root.
+func (c *Controller) verifyRoundTrip(
+	r dogma.ProcessRoot,
+	data []byte,
+	env *envelope.Envelope,
+) {
+	rt := c.Config.Source.Get().New()
+
+	if xreflect.IsNil(rt) {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "ProcessMessageHandler",
+			Method:         "New",
+			Implementation: c.Config.Implementation(),
+			Message:        env.Message,
+			Description:    "returned a nil process root",
+			Location:       location.OfMethod(c.Config.Implementation(), "New"),
+		})
+	}
+
+	if err := rt.UnmarshalBinary(data); err != nil {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "ProcessRoot",
+			Method:         "UnmarshalBinary",
+			Implementation: rt,
+			Message:        env.Message,
+			Description:    fmt.Sprintf("unable to unmarshal the process root: %s", err),
+			Location:       location.OfMethod(rt, "UnmarshalBinary"),
+		})
+	}
+
+	diffs := compare.Diff(r, rt)
+	if len(diffs) == 0 {
+		return
+	}
+
+	p := dapper.NewPrinter(dapper.WithPackagePaths(false))
+	desc := &strings.Builder{}
+	desc.WriteString("process root state differs after being marshaled and unmarshaled:")
+
+	for _, d := range diffs {
+		fmt.Fprintf(
+			desc,
+			"\n  %T%s: %s before marshaling, %s after unmarshaling",
+			r,
+			d.Path,
+			p.Format(d.A),
+			p.Format(d.B),
+		)
+	}
+
+	panic(panicx.UnexpectedBehavior{
+		Handler:        c.Config,
+		Interface:      "ProcessRoot",
+		Method:         "UnmarshalBinary",
+		Implementation: rt,
+		Message:        env.Message,
+		Description:    desc.String(),
+		Location:       location.OfMethod(rt, "UnmarshalBinary"),
+	})
+}
+
 // Reset clears the state of the controller.
 func (c *Controller) Reset() {
 	c.instances = nil
 	c.deadlines = nil
 }
 
-// route returns the ID of the instance that a message should be routed to.
-func (c *Controller) route(
+// Snapshot returns an opaque representation of the controller's state.
+func (c *Controller) Snapshot() any {
+	return state{c.instances, c.deadlines}.clone()
+}
+
+// Restore replaces the controller's state with a snapshot previously returned
+// by Snapshot().
+func (c *Controller) Restore(snapshot any) {
+	s := snapshot.(state).clone()
+	c.instances = s.instances
+	c.deadlines = s.deadlines
+}
+
+// InstanceIDs returns the IDs of all instances that have begun, including
+// those that have since ended, in lexical order.
+func (c *Controller) InstanceIDs() []string {
+	ids := slices.Collect(maps.Keys(c.instances))
+	slices.Sort(ids)
+	return ids
+}
+
+// Root returns the process root of the instance with the given ID, rebuilt
+// from the instance's serialized state.
+//
+// ok is false if the instance has never begun. ended is true if the instance
+// has ended.
+func (c *Controller) Root(id string) (root dogma.ProcessRoot, ended, ok bool) {
+	inst, ok := c.instances[id]
+	if !ok {
+		return nil, false, false
+	}
+
+	return c.rebuildRoot(inst), inst.ended, true
+}
+
+// rebuildRoot returns a new process root populated from the serialized state
+// of inst.
+func (c *Controller) rebuildRoot(inst *instance) dogma.ProcessRoot {
+	root := c.Config.Source.Get().New()
+
+	if xreflect.IsNil(root) {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "ProcessMessageHandler",
+			Method:         "New",
+			Implementation: c.Config.Implementation(),
+			Description:    "returned a nil process root",
+			Location:       location.OfMethod(c.Config.Implementation(), "New"),
+		})
+	}
+
+	if inst.mutated {
+		if err := root.UnmarshalBinary(inst.data); err != nil {
+			panic(panicx.UnexpectedBehavior{
+				Handler:        c.Config,
+				Interface:      "ProcessRoot",
+				Method:         "UnmarshalBinary",
+				Implementation: root,
+				Description:    fmt.Sprintf("unable to unmarshal the process root: %s", err),
+				Location:       location.OfMethod(root, "UnmarshalBinary"),
+			})
+		}
+	}
+
+	return root
+}
+
+// instanceState is the representation of a single instance's state returned
+// by InstanceSnapshot().
+type instanceState struct {
+	instance  *instance
+	deadlines []*envelope.Envelope
+}
+
+// InstanceSnapshot returns an opaque representation of the state of the
+// instance with the given ID, including its pending deadlines.
+func (c *Controller) InstanceSnapshot(id string) any {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	s := instanceState{}
+
+	if inst, ok := c.instances[id]; ok {
+		s.instance = inst.clone()
+	}
+
+	for _, env := range c.deadlines {
+		if env.Origin.InstanceID == id {
+			s.deadlines = append(s.deadlines, env)
+		}
+	}
+
+	return s
+}
+
+// InstanceChange returns a description of how the state of the instance with
+// the given ID differs from a snapshot previously returned by
+// InstanceSnapshot().
+//
+// ok is false if the state has not changed.
+func (c *Controller) InstanceChange(id string, snapshot any) (desc string, ok bool) {
+	before := snapshot.(instanceState)
+	after := c.InstanceSnapshot(id).(instanceState)
+
+	for _, env := range after.deadlines {
+		if !slices.Contains(before.deadlines, env) {
+			return fmt.Sprintf(
+				"scheduled a %s deadline",
+				message.TypeOf(env.Message),
+			), true
+		}
+	}
+
+	if before.instance == nil {
+		if after.instance == nil {
+			return "", false
+		}
+		return fmt.Sprintf("began the %q instance", id), true
+	}
+
+	if after.instance.ended && !before.instance.ended {
+		return fmt.Sprintf("ended the %q instance", id), true
+	}
+
+	if after.instance.mutated == before.instance.mutated &&
+		bytes.Equal(after.instance.data, before.instance.data) {
+		return "", false
+	}
+
+	// Compare the rebuilt roots rather than the serialized data, as
+	// MarshalBinary() is not required to be deterministic.
+	if len(compare.Diff(c.rebuildRoot(before.instance), c.rebuildRoot(after.instance))) == 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("modified the root of the %q instance", id), true
+}
+
+// Deadlines returns the deadline messages that have been scheduled but not yet
+// handled, in the order they are scheduled for.
+func (c *Controller) Deadlines() []*envelope.Envelope {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return slices.Clone(c.deadlines)
+}
+
+// Route returns the result of routing env to an instance, without notifying
+// any facts.
+//
+// Events are routed by the handler's RouteEventToInstance() method. Deadlines
+// are routed to the instance that scheduled them.
+func (c *Controller) Route(
 	ctx context.Context,
+	env *envelope.Envelope,
+) (r routing.Result) {
+	c.guardAgainstUnhandledMessage(env)
+
+	message.SwitchByKindOf(
+		env.Message,
+		nil,
+		func(m dogma.Event) {
+			panicx.EnrichUnexpectedMessage(
+				c.Config,
+				"ProcessMessageHandler",
+				"RouteEventToInstance",
+				c.Config.Implementation(),
+				m,
+				func() {
+					r.InstanceID, r.OK, r.Err = c.Config.Source.Get().RouteEventToInstance(ctx, m)
+				},
+			)
+
+			if r.Err != nil {
+				r.InstanceID, r.OK = "", false
+			}
+		},
+		func(dogma.Deadline) {
+			r.InstanceID, r.OK = env.Origin.InstanceID, true
+		},
+	)
+
+	return r
+}
+
+// guardAgainstUnhandledMessage panics if the handler does not handle messages
+// of the same type as env.
+func (c *Controller) guardAgainstUnhandledMessage(env *envelope.Envelope) {
+	mt := message.TypeOf(env.Message)
+
+	if !c.Config.RouteSet().DirectionOf(mt).Has(config.InboundDirection) {
+		panic(fmt.Sprintf("%s does not handle %s messages", c.Config.Identity(), mt))
+	}
+}
+
+// route returns the ID of the instance that a message should be routed to,
+// given the result of a prior call to Route().
+func (c *Controller) route(
 	obs fact.Observer,
 	env *envelope.Envelope,
+	r routing.Result,
 ) (id string, ok bool, err error) {
 	message.SwitchByKindOf(
 		env.Message,
 		nil,
-		func(m dogma.Event) { id, ok, err = c.routeEvent(ctx, obs, env, m) },
-		func(_ dogma.Deadline) { id, ok, err = c.routeDeadline(ctx, obs, env) },
+		func(m dogma.Event) { id, ok, err = c.routeEvent(obs, env, m, r) },
+		func(_ dogma.Deadline) { id, ok, err = c.routeDeadline(obs, env) },
 	)
 	return id, ok, err
 }
 
 func (c *Controller) routeEvent(
-	ctx context.Context,
 	obs fact.Observer,
 	env *envelope.Envelope,
 	m dogma.Event,
+	r routing.Result,
 ) (string, bool, error) {
-	handler := c.Config.Source.Get()
-
-	var (
-		id  string
-		ok  bool
-		err error
-	)
-	panicx.EnrichUnexpectedMessage(
-		c.Config,
-		"ProcessMessageHandler",
-		"RouteEventToInstance",
-		c.Config.Implementation(),
-		m,
-		func() {
-			id, ok, err = handler.RouteEventToInstance(ctx, m)
-		},
-	)
-
-	if err != nil {
-		return "", false, err
+	if r.Err != nil {
+		return "", false, r.Err
 	}
 
-	if !ok {
+	if !r.OK {
 		obs.Notify(fact.ProcessEventIgnored{
 			Handler:  c.Config,
 			Envelope: env,
@@ -282,7 +586,7 @@ func (c *Controller) routeEvent(
 		return "", false, nil
 	}
 
-	if id == "" {
+	if r.InstanceID == "" {
 		panic(panicx.UnexpectedBehavior{
 			Handler:        c.Config,
 			Interface:      "ProcessMessageHandler",
@@ -294,28 +598,29 @@ func (c *Controller) routeEvent(
 		})
 	}
 
-	if inst, ok := c.instances[id]; ok && inst.ended {
+	if c.ended(r.InstanceID) {
 		obs.Notify(fact.ProcessEventRoutedToEndedInstance{
 			Handler:    c.Config,
-			InstanceID: id,
+			InstanceID: r.InstanceID,
 			Envelope:   env,
 		})
 
 		return "", false, nil
 	}
 
-	return id, true, nil
+	return r.InstanceID, true, nil
 }
 
 func (c *Controller) routeDeadline(
-	_ context.Context,
 	obs fact.Observer,
 	env *envelope.Envelope,
 ) (string, bool, error) {
-	if inst, ok := c.instances[env.Origin.InstanceID]; ok {
-		if !inst.ended {
-			return env.Origin.InstanceID, true, nil
-		}
+	c.m.Lock()
+	inst, ok := c.instances[env.Origin.InstanceID]
+	c.m.Unlock()
+
+	if ok && !inst.ended {
+		return env.Origin.InstanceID, true, nil
 	}
 
 	obs.Notify(fact.ProcessDeadlineRoutedToEndedInstance{
@@ -359,8 +664,20 @@ func (c *Controller) handle(ctx context.Context, s *scope) error {
 	return err
 }
 
+// ended returns true if the instance with the given ID has ended.
+func (c *Controller) ended(id string) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	inst, ok := c.instances[id]
+	return ok && inst.ended
+}
+
 // scheduleDeadlines enqueues pending deadlines from the given scope.
 func (c *Controller) scheduleDeadlines(s *scope) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
 	c.deadlines = append(c.deadlines, s.pending...)
 
 	sort.Slice(
@@ -375,6 +692,9 @@ func (c *Controller) scheduleDeadlines(s *scope) {
 
 // cancelDeadlines removes an instance's deadlines.
 func (c *Controller) cancelDeadlines(id string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
 	c.deadlines = slices.DeleteFunc(
 		c.deadlines,
 		func(env *envelope.Envelope) bool {
@@ -13,6 +13,7 @@ import (
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	"github.com/dogmatiq/testkit/engine/internal/panicx"
 	. "github.com/dogmatiq/testkit/engine/internal/process"
+	"github.com/dogmatiq/testkit/engine/internal/routing"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
 	"github.com/dogmatiq/testkit/internal/x/xtesting"
@@ -1284,8 +1285,17 @@ func TestController(t *testing.T) {
 			)
 		})
 
-		t.Run("calls UnmarshalBinary when MarshalBinary returns nil", func(t *testing.T) {
+		t.Run("panics if the process root is not preserved when it is marshaled and unmarshaled", func(t *testing.T) {
 			f := newControllerTestFixture()
+			f.handler.NewFunc = func() *ProcessRootStub {
+				return &ProcessRootStub{
+					UnmarshalBinaryFunc: func([]byte) error {
+						// Deliberately does not restore the state that
+						// MarshalBinary produced.
+						return nil
+					},
+				}
+			}
 			f.handler.HandleEventFunc = func(
 				_ context.Context,
 				_ *ProcessRootStub,
@@ -1293,13 +1303,56 @@ func TestController(t *testing.T) {
 				_ dogma.Event,
 			) error {
 				s.Mutate(func(r *ProcessRootStub) {
-					r.MarshalBinaryFunc = func() ([]byte, error) {
-						return nil, nil
-					}
+					r.Value = "<value>"
 				})
 				return nil
 			}
 
+			xtesting.ExpectPanicMatching(t, func() {
+				_, _ = f.ctrl.Handle(
+					context.Background(),
+					fact.Ignore,
+					time.Now(),
+					f.event,
+				)
+			}, func(x panicx.UnexpectedBehavior) {
+				xtesting.Expect(t, "unexpected handler", x.Handler, f.cfg)
+				xtesting.Expect(t, "unexpected interface", x.Interface, "ProcessRoot")
+				xtesting.Expect(t, "unexpected method", x.Method, "UnmarshalBinary")
+				xtesting.Expect(t, "unexpected message", x.Message, f.event.Message)
+				xtesting.Expect(
+					t,
+					"unexpected description",
+					x.Description,
+					"process root state differs after being marshaled and unmarshaled:\n"+
+						`  *stubs.ProcessRootStub.Value: "<value>" before marshaling, any(nil) after unmarshaling`,
+				)
+				xtesting.ExpectLocation(t, x.Location, "/stubs/process.go")
+			})
+		})
+
+		t.Run("calls UnmarshalBinary when MarshalBinary returns nil", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.NewFunc = func() *ProcessRootStub {
+				return &ProcessRootStub{
+					MarshalBinaryFunc: func() ([]byte, error) {
+						return nil, nil
+					},
+					UnmarshalBinaryFunc: func([]byte) error {
+						return nil
+					},
+				}
+			}
+			f.handler.HandleEventFunc = func(
+				_ context.Context,
+				_ *ProcessRootStub,
+				s dogma.ProcessEventScope[*ProcessRootStub],
+				_ dogma.Event,
+			) error {
+				s.Mutate(func(*ProcessRootStub) {})
+				return nil
+			}
+
 			_, err := f.ctrl.Handle(
 				context.Background(),
 				fact.Ignore,
@@ -1335,17 +1388,23 @@ func TestController(t *testing.T) {
 
 		t.Run("calls UnmarshalBinary when MarshalBinary returns an empty slice", func(t *testing.T) {
 			f := newControllerTestFixture()
+			f.handler.NewFunc = func() *ProcessRootStub {
+				return &ProcessRootStub{
+					MarshalBinaryFunc: func() ([]byte, error) {
+						return []byte{}, nil
+					},
+					UnmarshalBinaryFunc: func([]byte) error {
+						return nil
+					},
+				}
+			}
 			f.handler.HandleEventFunc = func(
 				_ context.Context,
 				_ *ProcessRootStub,
 				s dogma.ProcessEventScope[*ProcessRootStub],
 				_ dogma.Event,
 			) error {
-				s.Mutate(func(r *ProcessRootStub) {
-					r.MarshalBinaryFunc = func() ([]byte, error) {
-						return []byte{}, nil
-					}
-				})
+				s.Mutate(func(*ProcessRootStub) {})
 				return nil
 			}
 
@@ -1383,6 +1442,216 @@ func TestController(t *testing.T) {
 		})
 	})
 
+	t.Run("InstanceIDs", func(t *testing.T) {
+		t.Run("returns the IDs of begun and ended instances", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.HandleEventFunc = func(
+				_ context.Context,
+				_ *ProcessRootStub,
+				s dogma.ProcessEventScope[*ProcessRootStub],
+				m dogma.Event,
+			) error {
+				if m == EventA2 {
+					s.End()
+				}
+				return nil
+			}
+
+			for _, m := range []dogma.Event{EventA1, EventA2} {
+				_, err := f.ctrl.Handle(
+					context.Background(),
+					fact.Ignore,
+					time.Now(),
+					envelope.NewEvent("1000", m, time.Now()),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			xtesting.Expect(
+				t,
+				"unexpected instance IDs",
+				f.ctrl.InstanceIDs(),
+				[]string{"<instance-A1>", "<instance-A2>"},
+			)
+		})
+	})
+
+	t.Run("Route", func(t *testing.T) {
+		t.Run("returns the ID of the instance that an event is routed to", func(t *testing.T) {
+			f := newControllerTestFixture()
+
+			xtesting.Expect(
+				t,
+				"unexpected result",
+				f.ctrl.Route(context.Background(), f.event),
+				routing.Result{InstanceID: "<instance-A1>", OK: true},
+			)
+		})
+
+		t.Run("returns the ID of the instance that a deadline originated from", func(t *testing.T) {
+			f := newControllerTestFixture()
+
+			xtesting.Expect(
+				t,
+				"unexpected result",
+				f.ctrl.Route(context.Background(), f.deadline),
+				routing.Result{InstanceID: "<instance-A1>", OK: true},
+			)
+		})
+
+		t.Run("returns false if the event is not routed to an instance", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.RouteEventToInstanceFunc = func(
+				context.Context,
+				dogma.Event,
+			) (string, bool, error) {
+				return "", false, nil
+			}
+
+			r := f.ctrl.Route(context.Background(), f.event)
+			xtesting.Expect(t, "unexpected ok", r.OK, false)
+		})
+
+		t.Run("returns the error if the handler fails to route the event", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.RouteEventToInstanceFunc = func(
+				context.Context,
+				dogma.Event,
+			) (string, bool, error) {
+				return "<instance>", true, errors.New("<error>")
+			}
+
+			r := f.ctrl.Route(context.Background(), f.event)
+			xtesting.Expect(t, "unexpected ok", r.OK, false)
+			xtesting.Expect(t, "unexpected instance ID", r.InstanceID, "")
+			if r.Err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	})
+
+	t.Run("HandleRoute", func(t *testing.T) {
+		t.Run("handles the event without routing it again", func(t *testing.T) {
+			f := newControllerTestFixture()
+			r := f.ctrl.Route(context.Background(), f.event)
+
+			f.handler.RouteEventToInstanceFunc = func(
+				context.Context,
+				dogma.Event,
+			) (string, bool, error) {
+				t.Fatal("unexpected call to RouteEventToInstance()")
+				return "", false, nil
+			}
+
+			if _, err := f.ctrl.HandleRoute(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.event,
+				r,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			xtesting.Expect(t, "unexpected instance IDs", f.ctrl.InstanceIDs(), []string{"<instance-A1>"})
+		})
+
+		t.Run("returns the routing error", func(t *testing.T) {
+			f := newControllerTestFixture()
+			expected := errors.New("<error>")
+
+			_, err := f.ctrl.HandleRoute(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.event,
+				routing.Result{Err: expected},
+			)
+
+			xtesting.Expect(t, "unexpected error", err, expected)
+		})
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		t.Run("returns the root with state from the prior Handle() call", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.HandleEventFunc = func(
+				_ context.Context,
+				_ *ProcessRootStub,
+				s dogma.ProcessEventScope[*ProcessRootStub],
+				_ dogma.Event,
+			) error {
+				s.Mutate(func(r *ProcessRootStub) {
+					r.Value = "<value>"
+				})
+				return nil
+			}
+
+			_, err := f.ctrl.Handle(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.event,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, ended, ok := f.ctrl.Root("<instance-A1>")
+			if !ok {
+				t.Fatal("expected instance to exist")
+			}
+
+			xtesting.Expect(t, "unexpected ended flag", ended, false)
+			xtesting.Expect(
+				t,
+				"unexpected root",
+				r,
+				dogma.ProcessRoot(&ProcessRootStub{Value: "<value>"}),
+			)
+		})
+
+		t.Run("reports that the instance has ended", func(t *testing.T) {
+			f := newControllerTestFixture()
+			f.handler.HandleEventFunc = func(
+				_ context.Context,
+				_ *ProcessRootStub,
+				s dogma.ProcessEventScope[*ProcessRootStub],
+				_ dogma.Event,
+			) error {
+				s.End()
+				return nil
+			}
+
+			_, err := f.ctrl.Handle(
+				context.Background(),
+				fact.Ignore,
+				time.Now(),
+				f.event,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, ended, ok := f.ctrl.Root("<instance-A1>")
+			if !ok {
+				t.Fatal("expected instance to exist")
+			}
+
+			xtesting.Expect(t, "unexpected ended flag", ended, true)
+		})
+
+		t.Run("returns false if the instance does not exist", func(t *testing.T) {
+			f := newControllerTestFixture()
+
+			if _, _, ok := f.ctrl.Root("<instance-A1>"); ok {
+				t.Fatal("did not expect instance to exist")
+			}
+		})
+	})
+
 	t.Run("Reset", func(t *testing.T) {
 		f := newControllerTestFixture()
 		f.handler.HandleEventFunc = func(
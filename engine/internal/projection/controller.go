@@ -11,6 +11,7 @@ import (
 	"github.com/dogmatiq/testkit/engine/internal/panicx"
 	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/location"
 )
 
 // CompactInterval is how frequently projections should be compacted.
@@ -35,45 +36,89 @@ func (c *Controller) HandlerConfig() config.Handler {
 	return c.Config
 }
 
-// Tick always performs projection compaction.
+// Tick performs projection compaction if at least CompactInterval has elapsed
+// since the projection was last compacted.
 func (c *Controller) Tick(
 	ctx context.Context,
 	obs fact.Observer,
 	now time.Time,
 ) ([]*envelope.Envelope, error) {
 	if now.Sub(c.lastCompact) >= CompactInterval {
-		c.lastCompact = now
+		return nil, c.Compact(ctx, obs, now)
+	}
 
-		obs.Notify(fact.ProjectionCompactionBegun{
-			Handler: c.Config,
-		})
+	return nil, nil
+}
 
-		err := c.Config.Source.Get().Compact(
-			ctx,
-			&scope{
-				config:   c.Config,
-				observer: obs,
-				now:      now,
-			},
-		)
+// Compact performs projection compaction immediately.
+func (c *Controller) Compact(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+) error {
+	c.lastCompact = now
 
-		obs.Notify(fact.ProjectionCompactionCompleted{
-			Handler: c.Config,
-			Error:   err,
-		})
+	obs.Notify(fact.ProjectionCompactionBegun{
+		Handler: c.Config,
+	})
 
-		return nil, err
-	}
+	err := c.Config.Source.Get().Compact(
+		ctx,
+		&scope{
+			config:   c.Config,
+			observer: obs,
+			now:      now,
+		},
+	)
 
-	return nil, nil
+	obs.Notify(fact.ProjectionCompactionCompleted{
+		Handler: c.Config,
+		Error:   err,
+	})
+
+	return err
 }
 
+// Fault is a fault that can be injected into the handling of an event in
+// order to exercise a projection's optimistic concurrency control.
+type Fault int
+
+const (
+	// NoFault handles the event normally.
+	NoFault Fault = iota
+
+	// CrashBeforeCommit discards the result of the handler's HandleEvent()
+	// method, as though the engine crashed before recording it, then delivers
+	// the event again.
+	//
+	// It panics if the handler's CheckpointOffset() method does not then
+	// report that the event has already been handled.
+	CrashBeforeCommit
+
+	// StaleCheckpoint delivers the event with a checkpoint offset that does
+	// not match the handler's, before delivering it normally.
+	//
+	// It panics if the handler applies the event despite the mismatch.
+	StaleCheckpoint
+)
+
 // Handle handles a message.
 func (c *Controller) Handle(
 	ctx context.Context,
 	obs fact.Observer,
 	now time.Time,
 	env *envelope.Envelope,
+) ([]*envelope.Envelope, error) {
+	return c.HandleWithFault(ctx, obs, now, env, NoFault)
+}
+
+// HandleWithFault handles a message, injecting the fault f.
+func (c *Controller) HandleWithFault(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	f Fault,
 ) ([]*envelope.Envelope, error) {
 	mt := message.TypeOf(env.Message)
 
@@ -95,9 +140,175 @@ func (c *Controller) Handle(
 	// If the checkpoint offset is greater than this event's offset, this
 	// message has already been processed.
 	if cp > env.EventStreamOffset {
+		obs.Notify(fact.ProjectionEventAlreadyHandled{
+			Handler:          c.Config,
+			Envelope:         env,
+			CheckpointOffset: cp,
+		})
+
 		return nil, nil
 	}
 
+	switch f {
+	case CrashBeforeCommit:
+		return nil, c.crashBeforeCommit(ctx, obs, now, env, cp)
+	case StaleCheckpoint:
+		if err := c.deliverStaleCheckpoint(ctx, obs, now, env, cp); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, c.handleEvent(ctx, obs, now, env, cp)
+}
+
+// crashBeforeCommit passes env to the handler's HandleEvent() method, then
+// discards the result and delivers env again, as per the CrashBeforeCommit
+// fault.
+func (c *Controller) crashBeforeCommit(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	cp uint64,
+) error {
+	err := c.handleEvent(ctx, obs, now, env, cp)
+
+	obs.Notify(fact.ProjectionCrashSimulated{
+		Handler:  c.Config,
+		Envelope: env,
+	})
+
+	if err != nil {
+		// The handler did not apply the event, so it is delivered again as
+		// normal.
+		_, err := c.Handle(ctx, obs, now, env)
+		return err
+	}
+
+	handler := c.Config.Source.Get()
+
+	cp, err = handler.CheckpointOffset(ctx, env.EventStreamID)
+	if err != nil {
+		return err
+	}
+
+	if cp <= env.EventStreamOffset {
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "ProjectionMessageHandler",
+			Method:         "CheckpointOffset",
+			Implementation: handler,
+			Message:        env.Message,
+			Description: fmt.Sprintf(
+				"reported a checkpoint offset of %d after handling the event at offset %d, so the event would be applied again after a crash",
+				cp,
+				env.EventStreamOffset,
+			),
+			Location: location.OfMethod(handler, "CheckpointOffset"),
+		})
+	}
+
+	obs.Notify(fact.ProjectionEventAlreadyHandled{
+		Handler:          c.Config,
+		Envelope:         env,
+		CheckpointOffset: cp,
+	})
+
+	return nil
+}
+
+// deliverStaleCheckpoint passes env to the handler's HandleEvent() method
+// with a checkpoint offset that does not match cp, the handler's actual
+// checkpoint offset, as per the StaleCheckpoint fault.
+func (c *Controller) deliverStaleCheckpoint(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	cp uint64,
+) error {
+	stale := cp + 1
+
+	obs.Notify(fact.ProjectionStaleCheckpointDelivered{
+		Handler:          c.Config,
+		Envelope:         env,
+		CheckpointOffset: stale,
+	})
+
+	next, compactErr, err := c.callHandleEvent(ctx, obs, now, env, stale)
+	if err != nil {
+		return err
+	}
+
+	if next == env.EventStreamOffset+1 {
+		handler := c.Config.Source.Get()
+
+		panic(panicx.UnexpectedBehavior{
+			Handler:        c.Config,
+			Interface:      "ProjectionMessageHandler",
+			Method:         "HandleEvent",
+			Implementation: handler,
+			Message:        env.Message,
+			Description: fmt.Sprintf(
+				"applied the event at offset %d even though the engine's checkpoint offset of %d did not match the projection's checkpoint offset of %d",
+				env.EventStreamOffset,
+				stale,
+				cp,
+			),
+			Location: location.OfMethod(handler, "HandleEvent"),
+		})
+	}
+
+	return compactErr
+}
+
+// handleEvent passes env to the handler's HandleEvent() method, given that the
+// engine believes the handler's checkpoint offset to be cp.
+//
+// It returns an error if the handler reports an optimistic concurrency
+// conflict.
+func (c *Controller) handleEvent(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	cp uint64,
+) error {
+	cp, compactErr, err := c.callHandleEvent(ctx, obs, now, env, cp)
+	if err != nil {
+		return err
+	}
+
+	if expect := env.EventStreamOffset + 1; cp != expect {
+		return fmt.Errorf(
+			"optimistic concurrency conflict when handling event at offset %d of stream %s: expected checkpoint offset of %d, handler returned %d",
+			env.EventStreamOffset,
+			env.EventStreamID,
+			expect,
+			cp,
+		)
+	}
+
+	// Finally we return the compaction error only if there was no other more
+	// relevant error.
+	return compactErr
+}
+
+// callHandleEvent passes env to the handler's HandleEvent() method, given that
+// the engine believes the handler's checkpoint offset to be cp, and returns
+// the checkpoint offset that the handler reports.
+//
+// compactErr is the error from the compaction that is performed in parallel
+// with the call if c.CompactDuringHandling is true.
+func (c *Controller) callHandleEvent(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+	env *envelope.Envelope,
+	cp uint64,
+) (_ uint64, compactErr, err error) {
+	handler := c.Config.Source.Get()
+
 	s := &scope{
 		config:     c.Config,
 		observer:   obs,
@@ -146,7 +357,7 @@ func (c *Controller) Handle(
 		},
 	)
 
-	compactErr := <-compactResult
+	compactErr = <-compactResult
 
 	if c.CompactDuringHandling {
 		obs.Notify(fact.ProjectionCompactionCompleted{
@@ -155,25 +366,51 @@ func (c *Controller) Handle(
 		})
 	}
 
-	if err != nil {
-		return nil, err
-	}
+	return cp, compactErr, err
+}
 
-	if expect := env.EventStreamOffset + 1; cp != expect {
-		return nil, fmt.Errorf(
-			"optimistic concurrency conflict when handling event at offset %d of stream %s: expected checkpoint offset of %d, handler returned %d",
-			env.EventStreamOffset,
-			env.EventStreamID,
-			expect,
-			cp,
-		)
-	}
+// ResetProjection clears the projection's data and checkpoint offsets by
+// calling the handler's Reset() method.
+func (c *Controller) ResetProjection(
+	ctx context.Context,
+	obs fact.Observer,
+	now time.Time,
+) error {
+	obs.Notify(fact.ProjectionResetBegun{
+		Handler: c.Config,
+	})
 
-	// Finally we return the compaction error only if there was no other more
-	// relevant error.
-	return nil, compactErr
+	err := c.Config.Source.Get().Reset(
+		ctx,
+		&scope{
+			config:   c.Config,
+			observer: obs,
+			now:      now,
+		},
+	)
+
+	obs.Notify(fact.ProjectionResetCompleted{
+		Handler: c.Config,
+		Error:   err,
+	})
+
+	return err
 }
 
 // Reset does nothing.
 func (c *Controller) Reset() {
 }
+
+// Snapshot returns an opaque representation of the controller's state.
+//
+// It does not include the state of the projection itself, which is managed by
+// the handler implementation.
+func (c *Controller) Snapshot() any {
+	return c.lastCompact
+}
+
+// Restore replaces the controller's state with a snapshot previously returned
+// by Snapshot().
+func (c *Controller) Restore(snapshot any) {
+	c.lastCompact = snapshot.(time.Time)
+}
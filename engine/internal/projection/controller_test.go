@@ -298,15 +298,29 @@ func TestControllerHandle(t *testing.T) {
 			return 0, nil
 		}
 
+		buf := &fact.Buffer{}
 		_, err := fx.ctrl.Handle(
 			context.Background(),
-			fact.Ignore,
+			buf,
 			time.Now(),
 			fx.event,
 		)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+
+		xtesting.Expect(
+			t,
+			"unexpected facts",
+			buf.Facts(),
+			[]fact.Fact{
+				fact.ProjectionEventAlreadyHandled{
+					Handler:          fx.cfg,
+					Envelope:         fx.event,
+					CheckpointOffset: 1,
+				},
+			},
+		)
 	})
 
 	t.Run("it returns an error if there is an optimistic concurrency conflict", func(t *testing.T) {
@@ -435,3 +449,38 @@ func TestControllerReset(t *testing.T) {
 	fx := newControllerTestFixture()
 	fx.ctrl.Reset()
 }
+
+func TestControllerResetProjection(t *testing.T) {
+	t.Run("it resets the projection", func(t *testing.T) {
+		fx := newControllerTestFixture()
+		expected := errors.New("<error>")
+
+		fx.handler.ResetFunc = func(
+			context.Context,
+			dogma.ProjectionResetScope,
+		) error {
+			return expected
+		}
+
+		buf := &fact.Buffer{}
+		err := fx.ctrl.ResetProjection(
+			context.Background(),
+			buf,
+			time.Now(),
+		)
+
+		xtesting.Expect(t, "unexpected reset error", err, expected)
+		xtesting.Expect(
+			t,
+			"unexpected reset facts",
+			buf.Facts(),
+			[]fact.Fact{
+				fact.ProjectionResetBegun{Handler: fx.cfg},
+				fact.ProjectionResetCompleted{
+					Handler: fx.cfg,
+					Error:   expected,
+				},
+			},
+		)
+	})
+}
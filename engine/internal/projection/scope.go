@@ -8,12 +8,12 @@ import (
 	"github.com/dogmatiq/testkit/fact"
 )
 
-// scope is an implementation of [dogma.ProjectionEventScope] and
-// [dogma.ProjectionCompactScope].
+// scope is an implementation of [dogma.ProjectionEventScope],
+// [dogma.ProjectionCompactScope] and [dogma.ProjectionResetScope].
 type scope struct {
 	config     *config.Projection
 	observer   fact.Observer
-	event      *envelope.Envelope // nil if compacting
+	event      *envelope.Envelope // nil if compacting or resetting
 	checkpoint uint64
 	now        time.Time
 }
@@ -41,7 +41,7 @@ func (s *scope) Now() time.Time {
 func (s *scope) Log(f string, v ...any) {
 	s.observer.Notify(fact.MessageLoggedByProjection{
 		Handler:      s.config,
-		Envelope:     s.event, // nil if compacting
+		Envelope:     s.event, // nil if compacting or resetting
 		LogFormat:    f,
 		LogArguments: v,
 	})
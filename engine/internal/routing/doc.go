@@ -0,0 +1,3 @@
+// Package routing describes the routing of messages to the instances of
+// aggregate and process message handlers.
+package routing
@@ -0,0 +1,13 @@
+package routing
+
+// Result is the result of routing a message to an instance of a handler.
+type Result struct {
+	// InstanceID is the ID of the instance that the message is routed to.
+	InstanceID string
+
+	// OK is false if the message is not routed to any instance.
+	OK bool
+
+	// Err is the error returned by the handler's routing method, if any.
+	Err error
+}
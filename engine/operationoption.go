@@ -117,6 +117,15 @@ type operationOptions struct {
 	enabledHandlerTypes map[config.HandlerType]bool
 	enabledHandlers     map[string]bool
 	idempotencyKey      string
+	faults              []*fault
+	deliveries          int
+	order               *randomOrder
+	concurrent          bool
+
+	// deferred, if non-nil, collects changes to the engine's state that are
+	// made while a message is handled concurrently with other messages, so
+	// that they can be applied in a deterministic order.
+	deferred *[]func()
 }
 
 // newOperationOptions returns a new operationOptions with the given options.
@@ -138,3 +147,25 @@ func newOperationOptions(e *Engine, options []OperationOption) *operationOptions
 
 	return oo
 }
+
+// notifyOrder notifies the observers of the seed used to randomize the dispatch
+// order, if any.
+func (oo *operationOptions) notifyOrder() {
+	if oo.order != nil {
+		oo.observers.Notify(
+			fact.DispatchOrderRandomized{
+				Seed: oo.order.seed,
+			},
+		)
+	}
+}
+
+// apply makes a change to the engine's state, or defers it if the message that
+// caused the change is being handled concurrently.
+func (oo *operationOptions) apply(fn func()) {
+	if oo.deferred != nil {
+		*oo.deferred = append(*oo.deferred, fn)
+	} else {
+		fn()
+	}
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// WithRandomOrder returns an operation option that randomizes the order in
+// which messages are dispatched, using a pseudo-random number generator
+// seeded with seed.
+//
+// Each message is delivered to the handlers that consume it in a random order,
+// and messages that are queued for dispatch are interleaved randomly, except
+// that events on the same event stream are always dispatched in order.
+//
+// The seed is recorded by a [fact.DispatchOrderRandomized] fact and included in
+// any error returned by the operation so that the order can be reproduced.
+//
+// By default, messages are dispatched in the order they are produced, and
+// handlers receive each message in the order they were registered.
+func WithRandomOrder(seed uint64) OperationOption {
+	return operationOptionFunc(func(_ *Engine, oo *operationOptions) {
+		oo.order = &randomOrder{
+			seed: seed,
+			rand: rand.New(rand.NewPCG(seed, seed)),
+		}
+	})
+}
+
+// randomOrder randomizes the order in which messages are dispatched.
+type randomOrder struct {
+	seed uint64
+	rand *rand.Rand
+}
+
+// next returns the index of the message in queue that should be dispatched
+// next.
+func (o *randomOrder) next(queue []*envelope.Envelope) int {
+	if o == nil {
+		return 0
+	}
+
+	var (
+		candidates []int
+		streams    = map[string]struct{}{}
+	)
+
+	for i, env := range queue {
+		if env.EventStreamID != "" {
+			if _, ok := streams[env.EventStreamID]; ok {
+				continue
+			}
+			streams[env.EventStreamID] = struct{}{}
+		}
+
+		candidates = append(candidates, i)
+	}
+
+	return candidates[o.rand.IntN(len(candidates))]
+}
+
+// shuffle returns the controllers in a random order.
+func (o *randomOrder) shuffle(controllers []controller) []controller {
+	if o == nil {
+		return controllers
+	}
+
+	shuffled := make([]controller, len(controllers))
+	copy(shuffled, controllers)
+
+	o.rand.Shuffle(
+		len(shuffled),
+		func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		},
+	)
+
+	return shuffled
+}
+
+// wrap adds the seed to err, if it is non-nil.
+func (o *randomOrder) wrap(err error) error {
+	if o == nil || err == nil {
+		return err
+	}
+
+	return fmt.Errorf("%w (dispatch order randomized using seed %d)", err, o.seed)
+}
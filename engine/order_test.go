@@ -0,0 +1,157 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestWithRandomOrder(t *testing.T) {
+	// handlingOrder returns the names of the handlers in the order they handled
+	// an event that is consumed by both the process and the projection.
+	handlingOrder := func(t *testing.T, seed uint64) []string {
+		t.Helper()
+
+		fx := newEngineFixture()
+		buf := &fact.Buffer{}
+
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			_ dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{})
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithObserver(buf),
+			WithRandomOrder(seed),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var order []string
+		for _, f := range buf.Facts() {
+			if f, ok := f.(fact.HandlingBegun); ok {
+				order = append(order, f.Handler.Identity().GetName())
+			}
+		}
+
+		return order
+	}
+
+	t.Run("it delivers messages to handlers in a random order", func(t *testing.T) {
+		orders := map[string]struct{}{}
+
+		for seed := range uint64(20) {
+			order := handlingOrder(t, seed)
+			orders[order[1]+","+order[2]] = struct{}{}
+		}
+
+		if len(orders) != 2 {
+			t.Fatalf("expected both handler orders to occur, got %v", orders)
+		}
+	})
+
+	t.Run("it produces the same order for the same seed", func(t *testing.T) {
+		for seed := range uint64(20) {
+			xtesting.Expect(
+				t,
+				"unexpected order",
+				handlingOrder(t, seed),
+				handlingOrder(t, seed),
+			)
+		}
+	})
+
+	t.Run("it dispatches events on the same stream in order", func(t *testing.T) {
+		for seed := range uint64(20) {
+			fx := newEngineFixture()
+
+			fx.aggregate.HandleCommandFunc = func(
+				_ *AggregateRootStub,
+				s dogma.AggregateCommandScope[*AggregateRootStub],
+				_ dogma.Command,
+			) {
+				s.RecordEvent(&engineAggregateEvent{Content: "<first>"})
+				s.RecordEvent(&engineAggregateEvent{Content: "<second>"})
+				s.RecordEvent(&engineAggregateEvent{Content: "<third>"})
+			}
+
+			var offsets []uint64
+			fx.projection.HandleEventFunc = func(
+				_ context.Context,
+				s dogma.ProjectionEventScope,
+				_ dogma.Event,
+			) (uint64, error) {
+				offsets = append(offsets, s.Offset())
+				return s.Offset() + 1, nil
+			}
+
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineAggregateCommand{},
+				WithRandomOrder(seed),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			xtesting.Expect(t, "unexpected offsets", offsets, []uint64{0, 1, 2})
+		}
+	})
+
+	t.Run("it records the seed", func(t *testing.T) {
+		fx := newEngineFixture()
+		buf := &fact.Buffer{}
+
+		err := fx.engine.Tick(
+			context.Background(),
+			WithObserver(buf),
+			WithRandomOrder(123),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.ExpectContains[fact.Fact](
+			t,
+			"expected DispatchOrderRandomized fact",
+			buf.Facts(),
+			fact.DispatchOrderRandomized{Seed: 123},
+		)
+	})
+
+	t.Run("it includes the seed in errors", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			return errors.New("<error>")
+		}
+
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithRandomOrder(123),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected error",
+			err.Error(),
+			"<integration> integration: <error> (dispatch order randomized using seed 123)",
+		)
+	})
+}
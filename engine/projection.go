@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/message"
+	"github.com/dogmatiq/testkit/engine/internal/projection"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+)
+
+// RebuildProjection resets the named projection and redelivers every event
+// that it consumes from the engine's event log.
+//
+// The projection is reset by calling its Reset() method, which clears its data
+// and checkpoint offsets. The events are then redelivered in the order they
+// were originally dispatched, with their original "recorded at" times and
+// stream offsets.
+//
+// Events are redelivered even if the projection is disabled, either by its
+// configuration or by the operation options. This allows a projection that is
+// enabled part-way through a test to catch up with the events it has missed.
+//
+// It returns an error without resetting the projection if any events have been
+// discarded from the event log. See WithEventLogLimit().
+//
+// The rebuild stops at the first event that the projection fails to handle,
+// and that error is returned. The engine's retry policy does not apply, and
+// the event is not dead-lettered.
+//
+// It panics if the application does not have a projection with the given name.
+func (e *Engine) RebuildProjection(
+	ctx context.Context,
+	name string,
+	options ...OperationOption,
+) error {
+	c := e.projectionController(name)
+	oo := newOperationOptions(e, options)
+
+	if err := e.m.Lock(ctx); err != nil {
+		return err
+	}
+	defer e.m.Unlock()
+
+	if e.events.discarded() != 0 {
+		return fmt.Errorf(
+			"%s %s: cannot rebuild the projection, events have been discarded from the event log",
+			name,
+			config.ProjectionHandlerType,
+		)
+	}
+
+	if err := c.ResetProjection(ctx, oo.observers, oo.now); err != nil {
+		return fmt.Errorf(
+			"%s %s: %w",
+			name,
+			config.ProjectionHandlerType,
+			err,
+		)
+	}
+
+	routes := c.Config.RouteSet()
+	events, _ := e.events.read(0, nil)
+
+	for _, env := range events {
+		mt := message.TypeOf(env.Message)
+
+		if !routes.DirectionOf(mt).Has(config.InboundDirection) {
+			continue
+		}
+
+		if err := e.rebuild(ctx, oo, env, c); err != nil {
+			return fmt.Errorf(
+				"%s %s: %w",
+				name,
+				config.ProjectionHandlerType,
+				err,
+			)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuild redelivers env to the projection controlled by c as part of a call
+// to RebuildProjection().
+//
+// Unlike attempt(), failures are neither retried nor dead-lettered, and fault
+// injection does not apply.
+func (e *Engine) rebuild(
+	ctx context.Context,
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c *projection.Controller,
+) error {
+	oo.observers.Notify(
+		fact.HandlingBegun{
+			Handler:  c.HandlerConfig(),
+			Envelope: env,
+		},
+	)
+
+	_, err := e.interceptHandle(
+		ctx,
+		oo,
+		env,
+		c,
+		func(ctx context.Context, env *envelope.Envelope) ([]*envelope.Envelope, error) {
+			return c.Handle(ctx, oo.observers, oo.now, env)
+		},
+	)
+
+	oo.observers.Notify(
+		fact.HandlingCompleted{
+			Handler:  c.HandlerConfig(),
+			Envelope: env,
+			Error:    err,
+		},
+	)
+
+	return err
+}
+
+// CompactProjection compacts the named projection immediately, at the engine
+// time given by the operation options.
+//
+// Projections are otherwise compacted by Tick(), at most once per hour of engine
+// time. The named projection is compacted even if it is disabled, and its next
+// periodic compaction is scheduled relative to this one.
+//
+// It panics if the application does not have a projection with the given name.
+func (e *Engine) CompactProjection(
+	ctx context.Context,
+	name string,
+	options ...OperationOption,
+) error {
+	c := e.projectionController(name)
+	oo := newOperationOptions(e, options)
+
+	if err := e.m.Lock(ctx); err != nil {
+		return err
+	}
+	defer e.m.Unlock()
+
+	if err := c.Compact(ctx, oo.observers, oo.now); err != nil {
+		return fmt.Errorf(
+			"%s %s: %w",
+			name,
+			config.ProjectionHandlerType,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// projectionController returns the controller for the named projection.
+func (e *Engine) projectionController(name string) *projection.Controller {
+	c, ok := e.controllers[name]
+	if !ok {
+		panic(fmt.Sprintf("the application does not have a handler named %q", name))
+	}
+
+	if c, ok := c.(*projection.Controller); ok {
+		return c
+	}
+
+	panic(fmt.Sprintf("the %q handler is not a projection", name))
+}
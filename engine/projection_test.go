@@ -0,0 +1,256 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_RebuildProjection(t *testing.T) {
+	type delivery struct {
+		Message    dogma.Message
+		RecordedAt time.Time
+		StreamID   string
+		Offset     uint64
+	}
+
+	setup := func(t *testing.T) (*engineFixture, *[]delivery, time.Time) {
+		fx := newEngineFixture()
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			m dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{
+				Content: m.(*engineAggregateCommand).Content,
+			})
+		}
+
+		var deliveries []delivery
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			m dogma.Event,
+		) (uint64, error) {
+			deliveries = append(deliveries, delivery{m, s.RecordedAt(), s.StreamID(), s.Offset()})
+			return s.Offset() + 1, nil
+		}
+
+		now := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+		for i, c := range []TypeA{"<first>", "<second>"} {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineAggregateCommand{Content: c},
+				WithCurrentTime(now.Add(time.Duration(i)*time.Hour)),
+				EnableProjections(false),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return fx, &deliveries, now
+	}
+
+	t.Run("it resets the projection and redelivers the events it consumes", func(t *testing.T) {
+		fx, deliveries, now := setup(t)
+
+		reset := false
+		fx.projection.ResetFunc = func(context.Context, dogma.ProjectionResetScope) error {
+			reset = true
+			return nil
+		}
+
+		buf := &fact.Buffer{}
+		if err := fx.engine.RebuildProjection(
+			context.Background(),
+			"<projection>",
+			WithCurrentTime(now.Add(24*time.Hour)),
+			WithObserver(buf),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reset {
+			t.Fatal("expected the projection to be reset")
+		}
+
+		if _, ok := findFact[fact.ProjectionResetCompleted](buf.Facts()); !ok {
+			t.Fatal("expected ProjectionResetCompleted fact")
+		}
+
+		if len(*deliveries) != 2 {
+			t.Fatalf("expected 2 events to be delivered, got %d", len(*deliveries))
+		}
+
+		first, second := (*deliveries)[0], (*deliveries)[1]
+
+		xtesting.Expect(t, "unexpected first event", first.Message, dogma.Message(&engineAggregateEvent{Content: "<first>"}))
+		xtesting.Expect(t, "unexpected second event", second.Message, dogma.Message(&engineAggregateEvent{Content: "<second>"}))
+		xtesting.Expect(t, "unexpected recorded-at time", first.RecordedAt, now)
+		xtesting.Expect(t, "unexpected recorded-at time", second.RecordedAt, now.Add(time.Hour))
+		xtesting.Expect(t, "unexpected stream ID", second.StreamID, first.StreamID)
+		xtesting.Expect(t, "unexpected offset", first.Offset, uint64(0))
+		xtesting.Expect(t, "unexpected offset", second.Offset, uint64(1))
+	})
+
+	t.Run("it returns an error if the projection cannot be reset", func(t *testing.T) {
+		fx, deliveries, _ := setup(t)
+
+		fx.projection.ResetFunc = func(context.Context, dogma.ProjectionResetScope) error {
+			return dogma.ErrNotSupported
+		}
+
+		err := fx.engine.RebuildProjection(context.Background(), "<projection>")
+		if !errors.Is(err, dogma.ErrNotSupported) {
+			t.Fatalf("unexpected error: got %v, want %v", err, dogma.ErrNotSupported)
+		}
+
+		xtesting.Expect(t, "unexpected number of deliveries", len(*deliveries), 0)
+	})
+
+	t.Run("it returns the first error without retrying or dead-lettering the event", func(t *testing.T) {
+		fx, deliveries, _ := setup(t)
+		fx.engine = MustNew(fx.cfg, WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+
+		for _, c := range []TypeA{"<first>", "<second>"} {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineAggregateCommand{Content: c},
+				EnableProjections(false),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := errors.New("<error>")
+		calls := 0
+		fx.projection.HandleEventFunc = func(
+			context.Context,
+			dogma.ProjectionEventScope,
+			dogma.Event,
+		) (uint64, error) {
+			calls++
+			return 0, want
+		}
+
+		err := fx.engine.RebuildProjection(context.Background(), "<projection>")
+		if !errors.Is(err, want) {
+			t.Fatalf("unexpected error: got %v, want %v", err, want)
+		}
+
+		xtesting.Expect(t, "unexpected number of calls", calls, 1)
+		xtesting.Expect(t, "unexpected number of deliveries", len(*deliveries), 0)
+		xtesting.Expect(t, "unexpected number of dead letters", len(fx.engine.DeadLetters()), 0)
+	})
+
+	t.Run("it returns an error if events have been discarded from the event log", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.engine = MustNew(fx.cfg, WithEventLogLimit(1))
+
+		var deliveries int
+		fx.projection.HandleEventFunc = func(
+			_ context.Context,
+			s dogma.ProjectionEventScope,
+			_ dogma.Event,
+		) (uint64, error) {
+			deliveries++
+			return s.Offset() + 1, nil
+		}
+
+		for range 2 {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineForeignEventForProjection{},
+				EnableProjections(false),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		reset := false
+		fx.projection.ResetFunc = func(context.Context, dogma.ProjectionResetScope) error {
+			reset = true
+			return nil
+		}
+
+		err := fx.engine.RebuildProjection(context.Background(), "<projection>")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected error",
+			err.Error(),
+			"<projection> projection: cannot rebuild the projection, events have been discarded from the event log",
+		)
+
+		if reset {
+			t.Fatal("did not expect the projection to be reset")
+		}
+
+		xtesting.Expect(t, "unexpected number of deliveries", deliveries, 0)
+	})
+
+	t.Run("it panics if the handler is not a projection", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<aggregate>" handler is not a projection`,
+			func() {
+				_ = fx.engine.RebuildProjection(context.Background(), "<aggregate>")
+			},
+		)
+	})
+}
+
+func TestEngine_CompactProjection(t *testing.T) {
+	t.Run("it compacts the projection immediately", func(t *testing.T) {
+		fx := newEngineFixture()
+		now := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+		var compactedAt []time.Time
+		fx.projection.CompactFunc = func(_ context.Context, s dogma.ProjectionCompactScope) error {
+			compactedAt = append(compactedAt, s.Now())
+			return nil
+		}
+
+		if err := fx.engine.Tick(context.Background(), WithCurrentTime(now)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fx.engine.CompactProjection(
+			context.Background(),
+			"<projection>",
+			WithCurrentTime(now.Add(time.Minute)),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected compaction times",
+			compactedAt,
+			[]time.Time{now, now.Add(time.Minute)},
+		)
+	})
+
+	t.Run("it returns the compaction error", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.projection.CompactFunc = func(context.Context, dogma.ProjectionCompactScope) error {
+			return errors.New("<error>")
+		}
+
+		err := fx.engine.CompactProjection(context.Background(), "<projection>")
+		xtesting.Expect(t, "unexpected error", err.Error(), "<projection> projection: <error>")
+	})
+}
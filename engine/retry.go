@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+)
+
+// RetryPolicy describes how the engine redelivers a message to a handler that
+// fails to handle it.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times that the engine attempts to
+	// handle each message, including the first attempt. It must be at least 1.
+	MaxAttempts int
+
+	// Backoff returns the delay, in engine time, before the given attempt is
+	// made. The second attempt is the first retry.
+	//
+	// If Backoff is nil, or returns a non-positive duration, the message is
+	// redelivered immediately. Otherwise, it is redelivered by the first call
+	// to Tick() at or after the engine time at which the retry is due.
+	Backoff func(attempt int) time.Duration
+}
+
+// WithRetryPolicy returns an engine option that enables at-least-once delivery
+// semantics.
+//
+// When a handler returns an error, the engine redelivers the message to that
+// handler according to p. Any messages produced during a failed attempt are
+// discarded. If the handler exhausts its attempts the message is added to the
+// engine's dead-letters, as returned by DeadLetters().
+//
+// Because failures are handled by redelivery, handler errors are not returned
+// by Dispatch() or Tick() when a retry policy is in use.
+//
+// By default, failed messages are not redelivered.
+func WithRetryPolicy(p RetryPolicy) Option {
+	if p.MaxAttempts < 1 {
+		panic(fmt.Sprintf("retry policy must allow at least 1 attempt, got %d", p.MaxAttempts))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.retryPolicy = &p
+	})
+}
+
+// retry is a pending redelivery of a message to a specific handler.
+type retry struct {
+	handler string
+	env     *envelope.Envelope
+	attempt int
+	at      time.Time
+}
+
+// retryImmediately handles a failed attempt to handle env using c, according
+// to the engine's retry policy.
+//
+// n is the number of the failed attempt. It returns true if the next attempt
+// should be made immediately.
+func (e *Engine) retryImmediately(
+	oo *operationOptions,
+	env *envelope.Envelope,
+	c controller,
+	n int,
+	err error,
+) bool {
+	h := c.HandlerConfig()
+
+	if n >= e.retryPolicy.MaxAttempts {
+		e.deadLetter(oo, env, c, n, err)
+		return false
+	}
+
+	var delay time.Duration
+	if e.retryPolicy.Backoff != nil {
+		delay = e.retryPolicy.Backoff(n + 1)
+	}
+
+	at := oo.now
+	if delay > 0 {
+		at = at.Add(delay)
+	}
+
+	oo.observers.Notify(
+		fact.RetryScheduled{
+			Handler:  h,
+			Envelope: env,
+			Error:    err,
+			Attempt:  n + 1,
+			RetryAt:  at,
+		},
+	)
+
+	if delay <= 0 {
+		return true
+	}
+
+	oo.apply(func() {
+		e.retries = append(
+			e.retries,
+			retry{
+				handler: h.Identity().GetName(),
+				env:     env,
+				attempt: n + 1,
+				at:      at,
+			},
+		)
+	})
+
+	return false
+}
+
+// redeliver makes the next attempt at handling each message with a retry that
+// is due at the current engine time.
+//
+// It returns the messages produced by the handlers that need to be dispatched
+// by the engine.
+func (e *Engine) redeliver(
+	ctx context.Context,
+	oo *operationOptions,
+) []*envelope.Envelope {
+	var (
+		queue   []*envelope.Envelope
+		pending []retry
+	)
+
+	retries := e.retries
+	e.retries = nil
+
+	for _, r := range retries {
+		c := e.controllers[r.handler]
+
+		if r.at.After(oo.now) || ctx.Err() != nil {
+			pending = append(pending, r)
+			continue
+		}
+
+		if skip, reason := e.skipHandler(c.HandlerConfig(), oo); skip {
+			oo.observers.Notify(
+				fact.HandlingSkipped{
+					Handler:  c.HandlerConfig(),
+					Envelope: r.env,
+					Reason:   reason,
+				},
+			)
+
+			pending = append(pending, r)
+			continue
+		}
+
+		// The retry policy is in use, so attempt() never returns an error.
+		envs, _ := e.attempt(ctx, oo, r.env, c, nil, r.attempt)
+		queue = append(queue, envs...)
+	}
+
+	e.retries = append(pending, e.retries...)
+
+	return queue
+}
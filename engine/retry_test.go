@@ -0,0 +1,181 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestWithRetryPolicy(t *testing.T) {
+	setup := func(
+		t *testing.T,
+		p RetryPolicy,
+		failures int,
+	) (*engineFixture, *int) {
+		fx := newEngineFixture()
+		fx.engine = MustNew(fx.cfg, WithRetryPolicy(p))
+
+		attempts := 0
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			attempts++
+			if attempts <= failures {
+				return errors.New("<error>")
+			}
+			return nil
+		}
+
+		return fx, &attempts
+	}
+
+	t.Run("it retries immediately when there is no backoff", func(t *testing.T) {
+		fx, attempts := setup(t, RetryPolicy{MaxAttempts: 3}, 2)
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithObserver(buf),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 3)
+		xtesting.Expect(t, "unexpected dead-letters", len(fx.engine.DeadLetters()), 0)
+
+		f, ok := findFact[fact.RetryScheduled](buf.Facts())
+		if !ok {
+			t.Fatal("expected RetryScheduled fact")
+		}
+		xtesting.Expect(t, "unexpected attempt", f.Attempt, 2)
+	})
+
+	t.Run("it retries on a tick once the backoff has elapsed", func(t *testing.T) {
+		fx, attempts := setup(
+			t,
+			RetryPolicy{
+				MaxAttempts: 2,
+				Backoff: func(int) time.Duration {
+					return time.Minute
+				},
+			},
+			1,
+		)
+
+		now := time.Now()
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithCurrentTime(now),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 1)
+
+		if err := fx.engine.Tick(
+			context.Background(),
+			WithCurrentTime(now.Add(59*time.Second)),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts before backoff", *attempts, 1)
+
+		if err := fx.engine.Tick(
+			context.Background(),
+			WithCurrentTime(now.Add(time.Minute)),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts after backoff", *attempts, 2)
+	})
+
+	t.Run("it dead-letters the message when the attempts are exhausted", func(t *testing.T) {
+		fx, attempts := setup(t, RetryPolicy{MaxAttempts: 2}, 10)
+
+		buf := &fact.Buffer{}
+		err := fx.engine.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithObserver(buf),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 2)
+
+		letters := fx.engine.DeadLetters()
+		if len(letters) != 1 {
+			t.Fatalf("expected 1 dead-letter, got %d", len(letters))
+		}
+
+		xtesting.Expect(t, "unexpected handler", letters[0].Handler.Identity().GetName(), "<integration>")
+		xtesting.Expect(t, "unexpected message", letters[0].Envelope.Message, dogma.Message(&engineIntegrationCommand{}))
+		xtesting.Expect(t, "unexpected error", letters[0].Error.Error(), "<error>")
+		xtesting.Expect(t, "unexpected attempts", letters[0].Attempts, 2)
+
+		if _, ok := findFact[fact.MessageDeadLettered](buf.Facts()); !ok {
+			t.Fatal("expected MessageDeadLettered fact")
+		}
+	})
+
+	t.Run("it clears dead-letters and pending retries when the engine is reset", func(t *testing.T) {
+		fx, attempts := setup(
+			t,
+			RetryPolicy{
+				MaxAttempts: 2,
+				Backoff: func(int) time.Duration {
+					return time.Minute
+				},
+			},
+			10,
+		)
+
+		now := time.Now()
+		for range 2 {
+			if err := fx.engine.Dispatch(
+				context.Background(),
+				&engineIntegrationCommand{},
+				WithCurrentTime(now),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fx.engine.Reset()
+
+		if err := fx.engine.Tick(
+			context.Background(),
+			WithCurrentTime(now.Add(time.Hour)),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		xtesting.Expect(t, "unexpected number of attempts", *attempts, 2)
+		xtesting.Expect(t, "unexpected dead-letters", len(fx.engine.DeadLetters()), 0)
+	})
+
+	t.Run("it panics if the policy does not allow any attempts", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"retry policy must allow at least 1 attempt, got 0",
+			func() {
+				WithRetryPolicy(RetryPolicy{})
+			},
+		)
+	})
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dogmatiq/testkit/envelope"
+)
+
+// Snapshot is an opaque representation of an engine's state at a specific
+// point in time.
+//
+// Snapshots are obtained by calling [Engine.Snapshot] and are applied by
+// calling [Engine.Restore]. A snapshot is immutable and may be restored any
+// number of times.
+type Snapshot struct {
+	messageID       uint64
+	events          []*envelope.Envelope
+	eventOffset     uint64
+	idempotencyKeys map[string]struct{}
+	history         messageHistory
+	retries         []retry
+	deadLetters     []DeadLetter
+	controllers     map[string]any
+}
+
+// Snapshot returns a snapshot of the engine's current state.
+//
+// The snapshot includes the state of every aggregate and process instance,
+// pending deadlines and retries, dead-letters, the offsets of each
+// integration's event stream, the event log, the idempotency keys that have
+// been used, and the position of the message ID sequence.
+//
+// It does not include any state that is managed by the handler implementations
+// themselves, such as projection data.
+func (e *Engine) Snapshot() *Snapshot {
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	events, eventOffset := e.events.snapshot()
+
+	s := &Snapshot{
+		messageID:       e.messageIDs.Snapshot(),
+		events:          events,
+		eventOffset:     eventOffset,
+		idempotencyKeys: maps.Clone(e.idempotencyKeys),
+		history:         e.history.clone(),
+		retries:         slices.Clone(e.retries),
+		deadLetters:     slices.Clone(e.deadLetters),
+		controllers:     make(map[string]any, len(e.controllers)),
+	}
+
+	for n, c := range e.controllers {
+		s.controllers[n] = c.Snapshot()
+	}
+
+	return s
+}
+
+// Restore replaces the engine's state with the state captured in s.
+//
+// s may have been obtained from a different engine, provided that both engines
+// were created from the same application configuration.
+//
+// Unlike Reset(), Restore() does not call the engine's reset hooks.
+//
+// It panics if s does not contain the state of each of the engine's handlers.
+func (e *Engine) Restore(s *Snapshot) {
+	_ = e.m.Lock(context.Background())
+	defer e.m.Unlock()
+
+	for n := range e.controllers {
+		if _, ok := s.controllers[n]; !ok {
+			panic(fmt.Sprintf("the snapshot does not contain the state of the %q handler", n))
+		}
+	}
+
+	e.messageIDs.Restore(s.messageID)
+	e.events.restore(s.events, s.eventOffset)
+	e.idempotencyKeys = maps.Clone(s.idempotencyKeys)
+	e.history = s.history.clone()
+	e.retries = slices.Clone(s.retries)
+	e.deadLetters = slices.Clone(s.deadLetters)
+
+	for n, c := range e.controllers {
+		c.Restore(s.controllers[n])
+	}
+}
+
+// Fork returns a new engine that begins in the same state as e.
+//
+// The new engine uses the same application configuration and engine options as
+// e, such that actions performed on one engine have no effect on the other. If
+// e uses an event store set by the WithEventStore() option, the new engine uses
+// the store returned by that store's Fork() method.
+//
+// Like Snapshot(), the new engine does not have its own copy of any state that
+// is managed by the handler implementations themselves.
+func (e *Engine) Fork() (*Engine, error) {
+	opts := *e.opts
+
+	if opts.eventStore != nil {
+		s, err := opts.eventStore.Fork()
+		if err != nil {
+			return nil, fmt.Errorf("unable to fork the event store: %w", err)
+		}
+
+		opts.eventStore = s
+	}
+
+	f := newEngine(e.app, &opts)
+	f.Restore(e.Snapshot())
+
+	return f, nil
+}
+
+// ForkOperationOptions returns a copy of options for use with an engine
+// returned by Fork().
+//
+// Options that keep state across operations, such as the faults injected by
+// FailHandler() and PanicHandler(), are replaced with copies of their current
+// state, such that operations performed with one set of options have no effect
+// on the other.
+func ForkOperationOptions(options []OperationOption) []OperationOption {
+	forked := slices.Clone(options)
+
+	for i, opt := range forked {
+		if f, ok := opt.(*fault); ok {
+			forked[i] = f.clone()
+		}
+	}
+
+	return forked
+}
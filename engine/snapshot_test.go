@@ -0,0 +1,228 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestEngine_Snapshot(t *testing.T) {
+	newFixture := func(t *testing.T) *engineFixture {
+		fx := newEngineFixture()
+
+		fx.aggregate.HandleCommandFunc = func(
+			_ *AggregateRootStub,
+			s dogma.AggregateCommandScope[*AggregateRootStub],
+			m dogma.Command,
+		) {
+			s.RecordEvent(&engineAggregateEvent{
+				Content: TypeA(m.(*engineAggregateCommand).Content),
+			})
+		}
+
+		fx.integration.HandleCommandFunc = func(
+			_ context.Context,
+			s dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			s.RecordEvent(EventB1)
+			return nil
+		}
+
+		return fx
+	}
+
+	dispatch := func(
+		t *testing.T,
+		fx *engineFixture,
+		m dogma.Message,
+		options ...OperationOption,
+	) *fact.Buffer {
+		t.Helper()
+
+		buf := &fact.Buffer{}
+		options = append(
+			options,
+			WithObserver(buf),
+			EnableProcesses(false),
+			EnableProjections(false),
+		)
+
+		if err := fx.engine.Dispatch(context.Background(), m, options...); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf
+	}
+
+	t.Run("it restores aggregate state", func(t *testing.T) {
+		fx := newFixture(t)
+
+		dispatch(t, fx, &engineAggregateCommand{Content: "<before>"})
+		snapshot := fx.engine.Snapshot()
+		dispatch(t, fx, &engineAggregateCommand{Content: "<after>"})
+
+		fx.engine.Restore(snapshot)
+
+		r, ok := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{Content: "<before>"},
+				},
+			}),
+		)
+	})
+
+	t.Run("it restores process state", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.process.HandleEventFunc = func(
+			_ context.Context,
+			_ *ProcessRootStub,
+			s dogma.ProcessEventScope[*ProcessRootStub],
+			m dogma.Event,
+		) error {
+			s.Mutate(func(r *ProcessRootStub) {
+				r.Value = string(m.(*engineForeignEventForProcess).Content)
+			})
+			return nil
+		}
+
+		var snapshot *Snapshot
+		for _, c := range []TypeC{"<before>", "<after>"} {
+			err := fx.engine.Dispatch(
+				context.Background(),
+				&engineForeignEventForProcess{Content: c},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshot == nil {
+				snapshot = fx.engine.Snapshot()
+			}
+		}
+
+		fx.engine.Restore(snapshot)
+
+		r, _, ok := fx.engine.ProcessRoot("<process>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.ProcessRoot(&ProcessRootStub{Value: "<before>"}),
+		)
+	})
+
+	t.Run("it restores the message ID sequence", func(t *testing.T) {
+		fx := newFixture(t)
+
+		dispatch(t, fx, &engineAggregateCommand{})
+		snapshot := fx.engine.Snapshot()
+		dispatch(t, fx, &engineAggregateCommand{})
+
+		fx.engine.Restore(snapshot)
+		buf := dispatch(t, fx, &engineAggregateCommand{})
+
+		f, ok := findFact[fact.DispatchCycleBegun](buf.Facts())
+		if !ok {
+			t.Fatal("expected DispatchCycleBegun fact")
+		}
+
+		xtesting.Expect(t, "unexpected message ID", f.Envelope.MessageID, "3")
+	})
+
+	t.Run("it restores the offsets of integration event streams", func(t *testing.T) {
+		fx := newFixture(t)
+
+		dispatch(t, fx, &engineIntegrationCommand{})
+		snapshot := fx.engine.Snapshot()
+		dispatch(t, fx, &engineIntegrationCommand{})
+
+		fx.engine.Restore(snapshot)
+		buf := dispatch(t, fx, &engineIntegrationCommand{})
+
+		f, ok := findFact[fact.EventRecordedByIntegration](buf.Facts())
+		if !ok {
+			t.Fatal("expected EventRecordedByIntegration fact")
+		}
+
+		xtesting.Expect(t, "unexpected offset", f.EventEnvelope.EventStreamOffset, uint64(1))
+	})
+
+	t.Run("it restores the idempotency keys", func(t *testing.T) {
+		fx := newFixture(t)
+
+		snapshot := fx.engine.Snapshot()
+		dispatch(t, fx, &engineAggregateCommand{}, WithIdempotencyKey("<key>"))
+
+		fx.engine.Restore(snapshot)
+		buf := dispatch(t, fx, &engineAggregateCommand{}, WithIdempotencyKey("<key>"))
+
+		if _, ok := findFact[fact.CommandDeduplicated](buf.Facts()); ok {
+			t.Fatal("did not expect the command to be deduplicated")
+		}
+	})
+
+	t.Run("it can restore the same snapshot more than once", func(t *testing.T) {
+		fx := newFixture(t)
+
+		snapshot := fx.engine.Snapshot()
+
+		for range 2 {
+			fx.engine.Restore(snapshot)
+			dispatch(t, fx, &engineAggregateCommand{})
+		}
+
+		r, _ := fx.engine.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{
+					&engineAggregateEvent{},
+				},
+			}),
+		)
+	})
+
+	t.Run("it can restore a snapshot taken from a different engine", func(t *testing.T) {
+		fx := newFixture(t)
+		dispatch(t, fx, &engineAggregateCommand{})
+
+		e := MustNew(fx.cfg)
+		e.Restore(fx.engine.Snapshot())
+
+		if _, ok := e.AggregateRoot("<aggregate>", "<instance>"); !ok {
+			t.Fatal("expected instance to exist")
+		}
+	})
+}
+
+func findFact[T fact.Fact](facts []fact.Fact) (T, bool) {
+	for _, f := range facts {
+		if x, ok := f.(T); ok {
+			return x, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// SnapshotPolicy describes when the engine takes snapshots of aggregate roots.
+//
+// The engine keeps each aggregate instance's root in memory between commands.
+// When a root is not in memory, such as after the engine's state is restored
+// by Restore(), it is loaded by unmarshaling the instance's most recent
+// snapshot and applying only those events that were recorded after the
+// snapshot was taken.
+//
+// The zero value is the engine's default policy, which takes a snapshot after
+// every command that records an event.
+type SnapshotPolicy struct {
+	// Mode determines when snapshots are taken. The zero value is
+	// SnapshotAtInterval.
+	Mode SnapshotMode
+
+	// Interval is the number of events that an aggregate instance must record
+	// after its most recent snapshot before the engine takes another snapshot.
+	// The snapshot is taken after handling the command that causes the
+	// instance to reach the interval.
+	//
+	// If it is zero or 1, a snapshot is taken after every command that
+	// records an event. It must not be negative, and it must be zero unless
+	// Mode is SnapshotAtInterval.
+	Interval int
+}
+
+// SnapshotMode is an enumeration of the ways in which a SnapshotPolicy decides
+// when to take snapshots of aggregate roots.
+type SnapshotMode int
+
+const (
+	// SnapshotAtInterval takes a snapshot once an aggregate instance has
+	// recorded the number of events given by SnapshotPolicy.Interval since its
+	// most recent snapshot.
+	SnapshotAtInterval SnapshotMode = iota
+
+	// SnapshotAlways takes a snapshot after every command that records an
+	// event.
+	SnapshotAlways
+
+	// SnapshotNever never takes snapshots. Aggregate roots that are not in
+	// memory are rebuilt from their entire history.
+	SnapshotNever
+)
+
+// WithSnapshotPolicy returns an engine option that sets the policy used to
+// decide when to take snapshots of aggregate roots.
+//
+// By default, a snapshot is taken after every command that records an event.
+func WithSnapshotPolicy(p SnapshotPolicy) Option {
+	switch p.Mode {
+	case SnapshotAtInterval:
+		if p.Interval < 0 {
+			panic(fmt.Sprintf("snapshot interval must not be negative, got %d", p.Interval))
+		}
+	case SnapshotAlways, SnapshotNever:
+		if p.Interval != 0 {
+			panic(fmt.Sprintf("snapshot interval must only be set when using SnapshotAtInterval, got %d", p.Interval))
+		}
+	default:
+		panic(fmt.Sprintf("unrecognized snapshot mode: %d", p.Mode))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.snapshotPolicy = &p
+	})
+}
+
+// SnapshotVerification describes how often the engine verifies the snapshots
+// of aggregate roots.
+//
+// Snapshots are verified each time an aggregate instance is loaded to handle a
+// command. A snapshot is verified by unmarshaling it, applying the events that
+// were recorded after it was taken, and comparing the result to a root that
+// has had the instance's entire history applied. The engine panics if the two
+// roots differ. Verification catches MarshalBinary() and UnmarshalBinary()
+// implementations that do not round-trip the root's state.
+type SnapshotVerification struct {
+	// Probability is the probability that the engine verifies an instance's
+	// snapshot each time the instance is loaded.
+	//
+	// If it is 1, snapshots are verified every time. If it is zero, snapshots
+	// are never verified. It must be between 0 and 1.
+	Probability float64
+
+	// Seed is used to seed the random number generator, such that the same
+	// snapshots are verified each time a test is run.
+	Seed uint64
+}
+
+// WithSnapshotVerification returns an engine option that sets how often the
+// engine verifies the snapshots of aggregate roots.
+//
+// By default, an instance's snapshot is verified every time the instance is
+// loaded.
+func WithSnapshotVerification(v SnapshotVerification) Option {
+	if v.Probability < 0 || v.Probability > 1 {
+		panic(fmt.Sprintf("snapshot verification probability must be between 0 and 1, got %v", v.Probability))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.snapshotVerification = &v
+	})
+}
+
+// snapshotInterval returns the snapshot interval to use for an aggregate
+// controller, according to the given policy.
+func snapshotInterval(p *SnapshotPolicy) int {
+	if p == nil {
+		return 0
+	}
+
+	switch p.Mode {
+	case SnapshotAlways:
+		return 0
+	case SnapshotNever:
+		return -1
+	default:
+		return p.Interval
+	}
+}
+
+// snapshotVerifier returns the function that an aggregate controller uses to
+// decide whether to verify an instance's snapshot.
+func snapshotVerifier(v *SnapshotVerification) func() bool {
+	if v == nil || v.Probability == 1 {
+		return nil
+	}
+
+	if v.Probability == 0 {
+		return func() bool { return false }
+	}
+
+	var (
+		m sync.Mutex
+		r = rand.New(rand.NewPCG(v.Seed, v.Seed))
+	)
+
+	return func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return r.Float64() < v.Probability
+	}
+}
@@ -0,0 +1,206 @@
+package engine_test
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+// dispatchAggregateCommands dispatches n commands to the aggregate in fx, each
+// of which records a single event.
+//
+// It returns the snapshot offset from each AggregateInstanceLoaded fact, along
+// with the number of events that had been applied to the loaded root.
+func dispatchAggregateCommands(
+	t *testing.T,
+	fx *engineFixture,
+	e *Engine,
+	n int,
+) (offsets, applied []int) {
+	t.Helper()
+
+	fx.aggregate.HandleCommandFunc = func(
+		_ *AggregateRootStub,
+		s dogma.AggregateCommandScope[*AggregateRootStub],
+		_ dogma.Command,
+	) {
+		s.RecordEvent(&engineAggregateEvent{})
+	}
+
+	obs := fact.ObserverFunc(func(f fact.Fact) {
+		if f, ok := f.(fact.AggregateInstanceLoaded); ok {
+			offsets = append(offsets, f.SnapshotOffset)
+			applied = append(applied, len(f.Root.(*AggregateRootStub).AppliedEvents))
+		}
+	})
+
+	for range n {
+		if err := e.Dispatch(
+			context.Background(),
+			&engineAggregateCommand{},
+			WithObserver(obs),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return offsets, applied
+}
+
+func TestWithSnapshotPolicy(t *testing.T) {
+	t.Run("it takes a snapshot once the interval is reached", func(t *testing.T) {
+		fx := newEngineFixture()
+		e := MustNew(fx.cfg, WithSnapshotPolicy(SnapshotPolicy{Interval: 3}))
+
+		offsets, applied := dispatchAggregateCommands(t, fx, e, 6)
+
+		xtesting.Expect(t, "unexpected snapshot offsets", offsets, []int{0, 0, 3, 3, 3})
+		xtesting.Expect(t, "unexpected number of applied events", applied, []int{1, 2, 3, 4, 5})
+	})
+
+	t.Run("it takes a snapshot after every command when the mode is SnapshotAlways", func(t *testing.T) {
+		fx := newEngineFixture()
+		e := MustNew(fx.cfg, WithSnapshotPolicy(SnapshotPolicy{Mode: SnapshotAlways}))
+
+		offsets, _ := dispatchAggregateCommands(t, fx, e, 3)
+		xtesting.Expect(t, "unexpected snapshot offsets", offsets, []int{1, 2})
+	})
+
+	t.Run("it never takes a snapshot when the mode is SnapshotNever", func(t *testing.T) {
+		fx := newEngineFixture()
+		e := MustNew(fx.cfg, WithSnapshotPolicy(SnapshotPolicy{Mode: SnapshotNever}))
+
+		offsets, _ := dispatchAggregateCommands(t, fx, e, 3)
+		xtesting.Expect(t, "unexpected snapshot offsets", offsets, []int{0, 0})
+	})
+
+	t.Run("it takes a snapshot after every command when the policy is the zero value", func(t *testing.T) {
+		fx := newEngineFixture()
+		e := MustNew(fx.cfg, WithSnapshotPolicy(SnapshotPolicy{}))
+
+		offsets, _ := dispatchAggregateCommands(t, fx, e, 3)
+		xtesting.Expect(t, "unexpected snapshot offsets", offsets, []int{1, 2})
+	})
+
+	t.Run("it panics if the interval is negative", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"snapshot interval must not be negative, got -1",
+			func() {
+				WithSnapshotPolicy(SnapshotPolicy{Interval: -1})
+			},
+		)
+	})
+
+	t.Run("it panics if the interval is set when the mode is not SnapshotAtInterval", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"snapshot interval must only be set when using SnapshotAtInterval, got 3",
+			func() {
+				WithSnapshotPolicy(SnapshotPolicy{Mode: SnapshotNever, Interval: 3})
+			},
+		)
+	})
+
+	t.Run("it panics if the mode is unrecognized", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"unrecognized snapshot mode: 100",
+			func() {
+				WithSnapshotPolicy(SnapshotPolicy{Mode: 100})
+			},
+		)
+	})
+}
+
+func TestWithSnapshotVerification(t *testing.T) {
+	// newFixture returns a fixture whose aggregate roots count the number of
+	// times they are unmarshaled. The engine keeps each instance's root in
+	// memory between commands, so a snapshot is only unmarshaled when it is
+	// verified, and when a root is copied for an AggregateInstanceLoaded fact.
+	newFixture := func() (*engineFixture, *int) {
+		fx := newEngineFixture()
+
+		var unmarshaled int
+		fx.aggregate.NewFunc = func() *AggregateRootStub {
+			r := &AggregateRootStub{}
+			r.MarshalBinaryFunc = func() ([]byte, error) {
+				return []byte(strconv.Itoa(len(r.AppliedEvents))), nil
+			}
+			r.UnmarshalBinaryFunc = func(data []byte) error {
+				unmarshaled++
+
+				n, err := strconv.Atoi(string(data))
+				if err != nil {
+					return err
+				}
+
+				r.AppliedEvents = slices.Repeat(
+					[]dogma.Event{&engineAggregateEvent{}},
+					n,
+				)
+
+				return nil
+			}
+			return r
+		}
+
+		return fx, &unmarshaled
+	}
+
+	// Each of these tests dispatches 21 commands to the same instance, which
+	// is loaded for each command except the first. Each load unmarshals one
+	// copy of the root, plus one more if the snapshot is verified.
+	const loads = 20
+
+	t.Run("it verifies every root by default", func(t *testing.T) {
+		fx, unmarshaled := newFixture()
+
+		dispatchAggregateCommands(t, fx, MustNew(fx.cfg), loads+1)
+
+		xtesting.Expect(t, "unexpected number of calls to UnmarshalBinary", *unmarshaled, loads*2)
+	})
+
+	t.Run("it does not verify roots when the probability is zero", func(t *testing.T) {
+		fx, unmarshaled := newFixture()
+		e := MustNew(fx.cfg, WithSnapshotVerification(SnapshotVerification{}))
+
+		dispatchAggregateCommands(t, fx, e, loads+1)
+
+		xtesting.Expect(t, "unexpected number of calls to UnmarshalBinary", *unmarshaled, loads)
+	})
+
+	t.Run("it verifies a sample of roots", func(t *testing.T) {
+		fx, unmarshaled := newFixture()
+		e := MustNew(
+			fx.cfg,
+			WithSnapshotVerification(SnapshotVerification{
+				Probability: 0.5,
+				Seed:        1,
+			}),
+		)
+
+		dispatchAggregateCommands(t, fx, e, loads+1)
+
+		if verified := *unmarshaled - loads; verified == 0 || verified == loads {
+			t.Fatalf("expected a sample of the roots to be verified, got %d of %d", verified, loads)
+		}
+	})
+
+	t.Run("it panics if the probability is out of range", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"snapshot verification probability must be between 0 and 1, got 1.5",
+			func() {
+				WithSnapshotVerification(SnapshotVerification{Probability: 1.5})
+			},
+		)
+	})
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+)
+
+// HandlerTimeout describes the timeout that the engine applies to each call to
+// a message handler.
+type HandlerTimeout struct {
+	// Duration is the amount of time that each call to a handler may take
+	// before its context is canceled. It must be positive.
+	//
+	// The timeout is measured by the wall clock. Likewise, the deadline
+	// reported by the context's Deadline() method is a wall-clock time.
+	//
+	// There is no option to measure the timeout in engine time. Engine time
+	// does not advance while a handler is running, so such a timeout could
+	// never expire, and reporting an engine-time deadline from a context that
+	// is canceled by the wall clock would be inconsistent.
+	Duration time.Duration
+}
+
+// WithHandlerTimeout returns an engine option that passes each call to a
+// handler's Handle() or Tick() method a context that is canceled once the
+// timeout has elapsed.
+//
+// By default, handlers are passed the context given to Dispatch() or Tick()
+// without any additional timeout.
+func WithHandlerTimeout(t HandlerTimeout) Option {
+	if t.Duration <= 0 {
+		panic(fmt.Sprintf("handler timeout must be positive, got %s", t.Duration))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.handlerTimeout = &t
+	})
+}
+
+// WithSlowHandlerThreshold returns an engine option that records a
+// [fact.SlowHandlerDetected] fact whenever a call to a handler's Handle() or
+// Tick() method takes longer than d, as measured by the wall clock.
+//
+// By default, the duration of handler calls is not measured.
+func WithSlowHandlerThreshold(d time.Duration) Option {
+	if d <= 0 {
+		panic(fmt.Sprintf("slow handler threshold must be positive, got %s", d))
+	}
+
+	return optionFunc(func(eo *engineOptions) {
+		eo.slowHandlerThreshold = d
+	})
+}
+
+// handlerContext returns the context to pass to a single call to a handler.
+func (e *Engine) handlerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.handlerTimeout == nil {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, e.handlerTimeout.Duration)
+}
+
+// detectSlowHandler notifies the observers if a call to h that began at start
+// took longer than the engine's slow handler threshold.
+//
+// env is nil if the call was to h's Tick() method.
+func (e *Engine) detectSlowHandler(
+	oo *operationOptions,
+	h config.Handler,
+	env *envelope.Envelope,
+	start time.Time,
+) {
+	if e.slowHandlerThreshold == 0 {
+		return
+	}
+
+	if d := time.Since(start); d > e.slowHandlerThreshold {
+		oo.observers.Notify(
+			fact.SlowHandlerDetected{
+				Handler:   h,
+				Envelope:  env,
+				Duration:  d,
+				Threshold: e.slowHandlerThreshold,
+			},
+		)
+	}
+}
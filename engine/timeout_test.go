@@ -0,0 +1,185 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	. "github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/envelope"
+	"github.com/dogmatiq/testkit/fact"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestWithHandlerTimeout(t *testing.T) {
+	t.Run("it cancels the handler's context once the timeout has elapsed", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			ctx context.Context,
+			_ dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		e := MustNew(
+			fx.cfg,
+			WithHandlerTimeout(HandlerTimeout{Duration: 10 * time.Millisecond}),
+		)
+
+		err := e.Dispatch(context.Background(), &engineIntegrationCommand{})
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: got %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("it reports the deadline on the wall clock", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		var deadline time.Time
+		fx.integration.HandleCommandFunc = func(
+			ctx context.Context,
+			_ dogma.IntegrationCommandScope,
+			_ dogma.Command,
+		) error {
+			deadline, _ = ctx.Deadline()
+			return nil
+		}
+
+		e := MustNew(
+			fx.cfg,
+			WithHandlerTimeout(HandlerTimeout{Duration: time.Minute}),
+		)
+
+		before := time.Now()
+		err := e.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithCurrentTime(time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after := time.Now()
+
+		if deadline.Before(before.Add(time.Minute)) || deadline.After(after.Add(time.Minute)) {
+			t.Fatalf(
+				"unexpected deadline: got %s, want between %s and %s",
+				deadline,
+				before.Add(time.Minute),
+				after.Add(time.Minute),
+			)
+		}
+	})
+
+	t.Run("it panics if the duration is not positive", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			"handler timeout must be positive, got 0s",
+			func() {
+				WithHandlerTimeout(HandlerTimeout{})
+			},
+		)
+	})
+}
+
+func TestWithSlowHandlerThreshold(t *testing.T) {
+	t.Run("it records a fact when handling takes longer than the threshold", func(t *testing.T) {
+		fx := newEngineFixture()
+		fx.integration.HandleCommandFunc = func(
+			context.Context,
+			dogma.IntegrationCommandScope,
+			dogma.Command,
+		) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}
+
+		e := MustNew(fx.cfg, WithSlowHandlerThreshold(time.Millisecond))
+		buf := &fact.Buffer{}
+
+		err := e.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithObserver(buf),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, ok := findFact[fact.SlowHandlerDetected](buf.Facts())
+		if !ok {
+			t.Fatal("expected SlowHandlerDetected fact")
+		}
+
+		xtesting.Expect(t, "unexpected handler", f.Handler.Identity().GetName(), "<integration>")
+		xtesting.Expect(t, "unexpected message", f.Envelope.Message, dogma.Message(&engineIntegrationCommand{}))
+		xtesting.Expect(t, "unexpected threshold", f.Threshold, time.Millisecond)
+
+		if f.Duration < 10*time.Millisecond {
+			t.Fatalf("unexpected duration: got %s, want at least 10ms", f.Duration)
+		}
+	})
+
+	t.Run("it records a fact when a tick takes longer than the threshold", func(t *testing.T) {
+		fx := newEngineFixture()
+
+		e := MustNew(
+			fx.cfg,
+			WithSlowHandlerThreshold(time.Millisecond),
+			WithInterceptor(&interceptorStub{
+				InterceptTickFunc: func(
+					ctx context.Context,
+					h config.Handler,
+					_ time.Time,
+					next TickFunc,
+				) ([]*envelope.Envelope, error) {
+					if h.Identity().GetName() == "<process>" {
+						time.Sleep(10 * time.Millisecond)
+					}
+					return next(ctx)
+				},
+			}),
+		)
+		buf := &fact.Buffer{}
+
+		if err := e.Tick(context.Background(), WithObserver(buf)); err != nil {
+			t.Fatal(err)
+		}
+
+		f, ok := findFact[fact.SlowHandlerDetected](buf.Facts())
+		if !ok {
+			t.Fatal("expected SlowHandlerDetected fact")
+		}
+
+		xtesting.Expect(t, "unexpected handler", f.Handler.Identity().GetName(), "<process>")
+
+		if f.Envelope != nil {
+			t.Fatal("expected envelope to be nil")
+		}
+	})
+
+	t.Run("it does not record a fact when handling is faster than the threshold", func(t *testing.T) {
+		fx := newEngineFixture()
+		e := MustNew(fx.cfg, WithSlowHandlerThreshold(time.Hour))
+		buf := &fact.Buffer{}
+
+		err := e.Dispatch(
+			context.Background(),
+			&engineIntegrationCommand{},
+			WithObserver(buf),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := findFact[fact.SlowHandlerDetected](buf.Facts()); ok {
+			t.Fatal("did not expect SlowHandlerDetected fact")
+		}
+	})
+}
@@ -90,6 +90,35 @@ func NewEvent(
 	}
 }
 
+// NewDeadline constructs a new envelope containing the given deadline message.
+//
+// It is used to deliver a deadline message directly to the process instance
+// described by o, rather than as a result of the instance scheduling it.
+//
+// t is the time at which the message was created. s is the time for which the
+// deadline is scheduled.
+func NewDeadline(
+	id string,
+	m dogma.Deadline,
+	t time.Time,
+	s time.Time,
+	o Origin,
+) *Envelope {
+	if id == "" {
+		panic("message ID must not be empty")
+	}
+
+	return &Envelope{
+		MessageID:     id,
+		CausationID:   id,
+		CorrelationID: id,
+		Message:       m,
+		CreatedAt:     t,
+		ScheduledFor:  s,
+		Origin:        &o,
+	}
+}
+
 var eventStreamNamespace = uuidpb.MustParse("8c1f42ee-d693-4e21-837e-54662b1c9ba3")
 
 // NewCommand constructs a new envelope as a child of e, indicating that the
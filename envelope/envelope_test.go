@@ -63,6 +63,50 @@ func TestEnvelope(t *testing.T) {
 		})
 	})
 
+	t.Run("func NewDeadline()", func(t *testing.T) {
+		t.Run("it returns the expected envelope", func(t *testing.T) {
+			handler := runtimeconfig.FromProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
+				ConfigureFunc: func(c dogma.ProcessConfigurer) {
+					c.Identity("<handler>", "1d4e3d22-52fe-4b1b-9bf5-44b2050c08c2")
+					c.Routes(
+						dogma.HandlesEvent[*EventStub[TypeA]](),
+						dogma.ExecutesCommand[*CommandStub[TypeA]](),
+						dogma.SchedulesDeadline[*DeadlineStub[TypeA]](),
+					)
+				},
+			})
+
+			origin := Origin{
+				Handler:     handler,
+				HandlerType: config.ProcessHandlerType,
+				InstanceID:  "<instance>",
+			}
+			now := time.Now()
+			env := NewDeadline(
+				"100",
+				DeadlineA1,
+				now,
+				now,
+				origin,
+			)
+
+			xtesting.Expect(
+				t,
+				"unexpected envelope",
+				env,
+				&Envelope{
+					MessageID:     "100",
+					CorrelationID: "100",
+					CausationID:   "100",
+					Message:       DeadlineA1,
+					CreatedAt:     now,
+					ScheduledFor:  now,
+					Origin:        &origin,
+				},
+			)
+		})
+	})
+
 	t.Run("func (Envelope) NewCommand()", func(t *testing.T) {
 		t.Run("it returns the expected envelope", func(t *testing.T) {
 			handler := runtimeconfig.FromProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
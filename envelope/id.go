@@ -22,3 +22,14 @@ func (g *MessageIDGenerator) Next() string {
 func (g *MessageIDGenerator) Reset() {
 	atomic.StoreUint64(&g.messageID, 0)
 }
+
+// Snapshot returns the generator's current position in the sequence.
+func (g *MessageIDGenerator) Snapshot() uint64 {
+	return atomic.LoadUint64(&g.messageID)
+}
+
+// Restore sets the generator's position in the sequence to a value previously
+// returned by Snapshot().
+func (g *MessageIDGenerator) Restore(n uint64) {
+	atomic.StoreUint64(&g.messageID, n)
+}
@@ -0,0 +1,207 @@
+package testkit
+
+import (
+	"fmt"
+
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/testkit/fact"
+)
+
+// ToCompactProjection returns an expectation that passes if the named
+// projection is compacted successfully.
+//
+// It is typically used with the CompactProjections() action.
+func ToCompactProjection(name string) Expectation {
+	return &compactionExpectation{
+		handler: name,
+		outcome: compactionSucceeded,
+	}
+}
+
+// ToFailToCompactProjection returns an expectation that passes if the named
+// projection returns an error when it is compacted.
+//
+// It is typically used with the CompactProjections() action.
+func ToFailToCompactProjection(name string) Expectation {
+	return &compactionExpectation{
+		handler: name,
+		outcome: compactionFailed,
+	}
+}
+
+// ToLogDuringProjectionCompaction returns an expectation that passes if the
+// named projection logs a specific message while it is being compacted.
+//
+// text is compared to the message after it has been formatted, that is, after
+// the arguments passed to the scope's Log() method have been substituted.
+//
+// It is typically used with the CompactProjections() action.
+func ToLogDuringProjectionCompaction(name, text string) Expectation {
+	return &compactionExpectation{
+		handler: name,
+		outcome: compactionLogged,
+		text:    text,
+	}
+}
+
+// compactionOutcome is an enumeration of the outcomes of compaction that can be
+// tested by a compactionExpectation.
+type compactionOutcome int
+
+const (
+	compactionSucceeded compactionOutcome = iota
+	compactionFailed
+	compactionLogged
+)
+
+// compactionExpectation is an Expectation that checks the outcome of
+// compacting a projection.
+//
+// It is the implementation used by ToCompactProjection(),
+// ToFailToCompactProjection() and ToLogDuringProjectionCompaction().
+type compactionExpectation struct {
+	handler string
+	outcome compactionOutcome
+	text    string
+}
+
+func (e *compactionExpectation) Caption() string {
+	return "to " + e.criteria()
+}
+
+func (e *compactionExpectation) Predicate(s PredicateScope) Predicate {
+	h, ok := s.App.HandlerByName(e.handler)
+	if !ok {
+		panic(fmt.Sprintf(
+			"the %q application does not have a handler named %q",
+			s.App.Identity().GetName(),
+			e.handler,
+		))
+	}
+
+	if h.HandlerType() != config.ProjectionHandlerType {
+		panic(fmt.Sprintf(
+			"the %q handler is not a projection",
+			e.handler,
+		))
+	}
+
+	return &compactionPredicate{
+		expectation: e,
+	}
+}
+
+// criteria returns a description of the expectation's requirement to pass.
+func (e *compactionExpectation) criteria() string {
+	switch e.outcome {
+	case compactionFailed:
+		return fmt.Sprintf("fail to compact the '%s' projection", e.handler)
+	case compactionLogged:
+		return fmt.Sprintf("log %q while compacting the '%s' projection", e.text, e.handler)
+	default:
+		return fmt.Sprintf("compact the '%s' projection", e.handler)
+	}
+}
+
+// compactionPredicate is the Predicate implementation for
+// compactionExpectation.
+type compactionPredicate struct {
+	expectation *compactionExpectation
+	ok          bool
+
+	compacting bool
+	compacted  int
+	errors     []error
+	logs       []string
+}
+
+func (p *compactionPredicate) Notify(f fact.Fact) {
+	switch x := f.(type) {
+	case fact.ProjectionCompactionBegun:
+		if x.Handler.Identity().GetName() == p.expectation.handler {
+			p.compacting = true
+		}
+
+	case fact.ProjectionCompactionCompleted:
+		if x.Handler.Identity().GetName() != p.expectation.handler {
+			return
+		}
+
+		p.compacting = false
+		p.compacted++
+
+		if x.Error != nil {
+			p.errors = append(p.errors, x.Error)
+		}
+
+		switch p.expectation.outcome {
+		case compactionSucceeded:
+			p.ok = p.ok || x.Error == nil
+		case compactionFailed:
+			p.ok = p.ok || x.Error != nil
+		}
+
+	case fact.MessageLoggedByProjection:
+		if !p.compacting ||
+			x.Envelope != nil ||
+			x.Handler.Identity().GetName() != p.expectation.handler {
+			return
+		}
+
+		text := fmt.Sprintf(x.LogFormat, x.LogArguments...)
+		p.logs = append(p.logs, text)
+
+		if p.expectation.outcome == compactionLogged {
+			p.ok = p.ok || text == p.expectation.text
+		}
+	}
+}
+
+func (p *compactionPredicate) Ok() bool {
+	return p.ok
+}
+
+func (p *compactionPredicate) Done() {
+}
+
+func (p *compactionPredicate) Report(ctx ReportGenerationContext) *Report {
+	rep := &Report{
+		TreeOk:   ctx.TreeOk,
+		Ok:       p.ok,
+		Criteria: p.expectation.criteria(),
+	}
+
+	if p.ok || ctx.IsInverted {
+		return rep
+	}
+
+	if p.compacted == 0 {
+		rep.Outcome = "the projection was not compacted"
+
+		rep.Section(suggestionsSection).AppendListItem(
+			"use the CompactProjections() action to compact the projection immediately",
+		)
+
+		return rep
+	}
+
+	switch p.expectation.outcome {
+	case compactionSucceeded:
+		rep.Outcome = "the projection could not be compacted"
+		rep.Explanation = p.errors[len(p.errors)-1].Error()
+	case compactionFailed:
+		rep.Outcome = "the projection was compacted successfully"
+	case compactionLogged:
+		rep.Outcome = "the expected message was not logged"
+	}
+
+	if len(p.logs) != 0 {
+		s := rep.Section(logSection)
+
+		for _, m := range p.logs {
+			s.Append("%s", m)
+		}
+	}
+
+	return rep
+}
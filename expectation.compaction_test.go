@@ -0,0 +1,205 @@
+package testkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestCompactionExpectations(t *testing.T) {
+	newFixture := func(err error, logs ...string) (*testingmock.T, *Test) {
+		mt := &testingmock.T{FailSilently: true}
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "a7c3e1f9-2b4d-4e8a-9c6b-0d5f3a1e7b2c")
+				c.Routes(
+					dogma.ViaProjection(&ProjectionMessageHandlerStub{
+						ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+							c.Identity("<projection>", "3e9b7d1c-5a2f-4c8e-b6d0-1f4a8c2e9b5d")
+							c.Routes(
+								dogma.HandlesEvent[*EventStub[TypeA]](),
+							)
+						},
+						CompactFunc: func(
+							_ context.Context,
+							s dogma.ProjectionCompactScope,
+						) error {
+							for _, l := range logs {
+								s.Log("%s", l)
+							}
+							return err
+						},
+					}),
+					dogma.ViaIntegration(&IntegrationMessageHandlerStub{
+						ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+							c.Identity("<integration>", "6d2f8a4c-1e7b-4b3d-9a5e-c8f0b2d4e6a1")
+							c.Routes(
+								dogma.HandlesCommand[*CommandStub[TypeA]](),
+							)
+						},
+					}),
+				)
+			},
+		}
+
+		return mt, Begin(mt, app)
+	}
+
+	cases := []struct {
+		Name        string
+		Error       error
+		Logs        []string
+		Action      Action
+		Expectation Expectation
+		Passes      bool
+		Report      reportMatcher
+	}{
+		{
+			"ToCompactProjection() passes when the projection is compacted successfully",
+			nil,
+			nil,
+			CompactProjections("<projection>"),
+			ToCompactProjection("<projection>"),
+			expectPass,
+			expectReport(
+				`✓ compact the '<projection>' projection`,
+			),
+		},
+		{
+			"ToCompactProjection() fails when compaction returns an error",
+			errors.New("<error>"),
+			nil,
+			CompactProjections("<projection>"),
+			ToCompactProjection("<projection>"),
+			expectFail,
+			expectReport(
+				`✗ compact the '<projection>' projection (the projection could not be compacted)`,
+				``,
+				`  | EXPLANATION`,
+				`  |     <error>`,
+			),
+		},
+		{
+			"ToCompactProjection() fails when the projection is not compacted",
+			nil,
+			nil,
+			noop,
+			ToCompactProjection("<projection>"),
+			expectFail,
+			expectReport(
+				`✗ compact the '<projection>' projection (the projection was not compacted)`,
+				``,
+				`  | SUGGESTIONS`,
+				`  |     • use the CompactProjections() action to compact the projection immediately`,
+			),
+		},
+		{
+			"ToFailToCompactProjection() passes when compaction returns an error",
+			errors.New("<error>"),
+			nil,
+			CompactProjections(),
+			ToFailToCompactProjection("<projection>"),
+			expectPass,
+			expectReport(
+				`✓ fail to compact the '<projection>' projection`,
+			),
+		},
+		{
+			"ToFailToCompactProjection() fails when the projection is compacted successfully",
+			nil,
+			nil,
+			CompactProjections(),
+			ToFailToCompactProjection("<projection>"),
+			expectFail,
+			expectReport(
+				`✗ fail to compact the '<projection>' projection (the projection was compacted successfully)`,
+			),
+		},
+		{
+			"ToLogDuringProjectionCompaction() passes when the message is logged",
+			nil,
+			[]string{"<first>", "<second>"},
+			CompactProjections("<projection>"),
+			ToLogDuringProjectionCompaction("<projection>", "<second>"),
+			expectPass,
+			expectReport(
+				`✓ log "<second>" while compacting the '<projection>' projection`,
+			),
+		},
+		{
+			"ToLogDuringProjectionCompaction() fails when the message is not logged",
+			nil,
+			[]string{"<first>"},
+			CompactProjections("<projection>"),
+			ToLogDuringProjectionCompaction("<projection>", "<second>"),
+			expectFail,
+			expectReport(
+				`✗ log "<second>" while compacting the '<projection>' projection (the expected message was not logged)`,
+				``,
+				`  | LOG MESSAGES`,
+				`  |     <first>`,
+			),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			mt, tc := newFixture(c.Error, c.Logs...)
+			tc.Expect(c.Action, c.Expectation)
+			c.Report(mt)
+			if mt.Failed() != !c.Passes {
+				t.Fatalf("testingT.Failed() = %v, want %v", mt.Failed(), !c.Passes)
+			}
+		})
+	}
+
+	t.Run("it produces the expected caption", func(t *testing.T) {
+		mt, tc := newFixture(nil)
+		tc.Expect(
+			CompactProjections("<projection>"),
+			ToCompactProjection("<projection>"),
+		)
+		xtesting.ExpectContains(
+			t,
+			"expected log message not found",
+			mt.Logs,
+			`--- expect compacting the "<projection>" projection to compact the '<projection>' projection ---`,
+		)
+	})
+
+	t.Run("it panics if the handler is not recognized", func(t *testing.T) {
+		_, tc := newFixture(nil)
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<app>" application does not have a handler named "<unknown>"`,
+			func() {
+				tc.Expect(
+					CompactProjections("<projection>"),
+					ToCompactProjection("<unknown>"),
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if the handler is not a projection", func(t *testing.T) {
+		_, tc := newFixture(nil)
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<integration>" handler is not a projection`,
+			func() {
+				tc.Expect(
+					CompactProjections("<projection>"),
+					ToFailToCompactProjection("<integration>"),
+				)
+			},
+		)
+	})
+}
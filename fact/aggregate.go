@@ -8,6 +8,11 @@ import (
 
 // AggregateInstanceLoaded indicates that an aggregate message handler has
 // loaded an existing instance in order to handle a command.
+//
+// Root is a copy of the instance's root as it was when the instance was loaded.
+// If the root does not support marshaling it can not be copied, and Root is
+// instead a separate root that the engine keeps up to date as the instance
+// records further events.
 type AggregateInstanceLoaded struct {
 	Handler        *config.Aggregate
 	InstanceID     string
@@ -43,6 +48,18 @@ type EventRecordedByAggregate struct {
 	EventEnvelope *envelope.Envelope
 }
 
+// EventSeededIntoAggregate indicates that an event was added to an aggregate
+// instance's history without the aggregate handling a command, such as by the
+// GivenAggregateEvents() action.
+//
+// Root is the instance's root after the event has been applied.
+type EventSeededIntoAggregate struct {
+	Handler       *config.Aggregate
+	InstanceID    string
+	Root          dogma.AggregateRoot
+	EventEnvelope *envelope.Envelope
+}
+
 // MessageLoggedByAggregate indicates that an aggregate wrote a log message
 // while handling a command.
 type MessageLoggedByAggregate struct {
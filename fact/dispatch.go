@@ -66,3 +66,68 @@ type CommandDeduplicated struct {
 	Envelope *envelope.Envelope
 	Key      string
 }
+
+// FaultInjected indicates that a fault has been injected into a handler in
+// place of handling a message, as configured by the engine.FailHandler() or
+// engine.PanicHandler() operation options.
+//
+// The handler itself is not invoked.
+type FaultInjected struct {
+	Handler  config.Handler
+	Envelope *envelope.Envelope
+
+	// Error is the error that the handler returns instead of handling the
+	// message. It is nil if the fault is a panic.
+	Error error
+
+	// PanicValue is the value that the handler panics with instead of handling
+	// the message. It is nil if the fault is an error.
+	PanicValue any
+}
+
+// RetryScheduled indicates that a handler failed to handle a message and that
+// the engine will redeliver the message to the handler according to its retry
+// policy.
+type RetryScheduled struct {
+	Handler  config.Handler
+	Envelope *envelope.Envelope
+	Error    error
+
+	// Attempt is the number of the attempt that will be made when the message
+	// is redelivered. The first attempt is 1.
+	Attempt int
+
+	// RetryAt is the engine time at which the message will be redelivered.
+	RetryAt time.Time
+}
+
+// MessageDeadLettered indicates that a handler failed to handle a message and
+// that the engine will not redeliver the message, either because the handler
+// has exhausted the attempts allowed by the engine's retry policy, or because
+// the engine does not have a retry policy.
+type MessageDeadLettered struct {
+	Handler  config.Handler
+	Envelope *envelope.Envelope
+	Error    error
+	Attempts int
+}
+
+// DispatchOrderRandomized indicates that the engine is dispatching messages in
+// a random order during a call to Engine.Dispatch() or Engine.Tick(), as
+// configured by the engine.WithRandomOrder() operation option.
+//
+// Seed can be used to reproduce the same order.
+type DispatchOrderRandomized struct {
+	Seed uint64
+}
+
+// SlowHandlerDetected indicates that a call to a handler took longer than the
+// threshold configured by the engine.WithSlowHandlerThreshold() option.
+//
+// Envelope is nil if the handler was performing a tick.
+type SlowHandlerDetected struct {
+	Handler   config.Handler
+	Envelope  *envelope.Envelope
+	Duration  time.Duration
+	Threshold time.Duration
+}
@@ -33,10 +33,20 @@ func (l *Logger) Notify(f Fact) {
 		l.dispatchBegun(x)
 	case CommandDeduplicated:
 		l.commandDeduplicated(x)
+	case DispatchOrderRandomized:
+		l.dispatchOrderRandomized(x)
 	case HandlingCompleted:
 		l.handlingCompleted(x)
 	case HandlingSkipped:
 		l.handlingSkipped(x)
+	case FaultInjected:
+		l.faultInjected(x)
+	case RetryScheduled:
+		l.retryScheduled(x)
+	case MessageDeadLettered:
+		l.messageDeadLettered(x)
+	case SlowHandlerDetected:
+		l.slowHandlerDetected(x)
 	case TickCycleBegun:
 		l.tickCycleBegun(x)
 	case TickCompleted:
@@ -49,6 +59,8 @@ func (l *Logger) Notify(f Fact) {
 		l.aggregateInstanceCreated(x)
 	case EventRecordedByAggregate:
 		l.eventRecordedByAggregate(x)
+	case EventSeededIntoAggregate:
+		l.eventSeededIntoAggregate(x)
 	case MessageLoggedByAggregate:
 		l.messageLoggedByAggregate(x)
 	case ProcessInstanceLoaded:
@@ -77,6 +89,14 @@ func (l *Logger) Notify(f Fact) {
 		l.messageLoggedByIntegration(x)
 	case ProjectionCompactionCompleted:
 		l.projectionCompactionCompleted(x)
+	case ProjectionResetCompleted:
+		l.projectionResetCompleted(x)
+	case ProjectionEventAlreadyHandled:
+		l.projectionEventAlreadyHandled(x)
+	case ProjectionCrashSimulated:
+		l.projectionCrashSimulated(x)
+	case ProjectionStaleCheckpointDelivered:
+		l.projectionStaleCheckpointDelivered(x)
 	case MessageLoggedByProjection:
 		l.messageLoggedByProjection(x)
 	}
@@ -129,6 +149,19 @@ func (l *Logger) commandDeduplicated(f CommandDeduplicated) {
 	)
 }
 
+// dispatchOrderRandomized returns the log message for f.
+func (l *Logger) dispatchOrderRandomized(f DispatchOrderRandomized) {
+	l.log(
+		&envelope.Envelope{},
+		[]logging.Icon{
+			"",
+			logging.SystemIcon,
+			"",
+		},
+		fmt.Sprintf("dispatch order randomized using seed %d", f.Seed),
+	)
+}
+
 // handlingCompleted returns the log message for f.
 func (l *Logger) handlingCompleted(f HandlingCompleted) {
 	if f.Error != nil {
@@ -170,6 +203,86 @@ func (l *Logger) handlingSkipped(f HandlingSkipped) {
 	)
 }
 
+// faultInjected returns the log message for f.
+func (l *Logger) faultInjected(f FaultInjected) {
+	text := fmt.Sprintf("fault injected, panicking with %v", f.PanicValue)
+	if f.Error != nil {
+		text = fmt.Sprintf("fault injected, returning error: %s", f.Error)
+	}
+
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.InboundErrorIcon,
+			logging.HandlerTypeIcon(f.Handler.HandlerType()),
+			logging.ErrorIcon,
+		},
+		f.Handler.Identity().GetName(),
+		text,
+	)
+}
+
+// retryScheduled returns the log message for f.
+func (l *Logger) retryScheduled(f RetryScheduled) {
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.RetryIcon,
+			logging.HandlerTypeIcon(f.Handler.HandlerType()),
+			"",
+		},
+		f.Handler.Identity().GetName(),
+		fmt.Sprintf(
+			"attempt #%d scheduled for %s",
+			f.Attempt,
+			formatEngineTime(f.RetryAt),
+		),
+	)
+}
+
+// messageDeadLettered returns the log message for f.
+func (l *Logger) messageDeadLettered(f MessageDeadLettered) {
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.InboundErrorIcon,
+			logging.HandlerTypeIcon(f.Handler.HandlerType()),
+			logging.ErrorIcon,
+		},
+		f.Handler.Identity().GetName(),
+		fmt.Sprintf("message dead-lettered after %d attempt(s)", f.Attempts),
+	)
+}
+
+// slowHandlerDetected returns the log message for f.
+func (l *Logger) slowHandlerDetected(f SlowHandlerDetected) {
+	env := f.Envelope
+	icon := logging.InboundIcon
+	call := "handling"
+
+	if env == nil {
+		env = &envelope.Envelope{}
+		icon = ""
+		call = "tick"
+	}
+
+	l.log(
+		env,
+		[]logging.Icon{
+			icon,
+			logging.HandlerTypeIcon(f.Handler.HandlerType()),
+			"",
+		},
+		f.Handler.Identity().GetName(),
+		fmt.Sprintf(
+			"slow %s took %s, exceeding the threshold of %s",
+			call,
+			f.Duration,
+			f.Threshold,
+		),
+	)
+}
+
 // tickCycleBegun returns the log message for f.
 func (l *Logger) tickCycleBegun(f TickCycleBegun) {
 	l.log(
@@ -264,6 +377,25 @@ func (l *Logger) eventRecordedByAggregate(f EventRecordedByAggregate) {
 	)
 }
 
+// eventSeededIntoAggregate returns the log message for f.
+func (l *Logger) eventSeededIntoAggregate(f EventSeededIntoAggregate) {
+	mt := message.TypeOf(f.EventEnvelope.Message)
+
+	l.log(
+		f.EventEnvelope,
+		[]logging.Icon{
+			logging.OutboundIcon,
+			logging.AggregateIcon,
+			"",
+		},
+		f.Handler.Identity().GetName()+" "+f.InstanceID,
+		f.Root.AggregateInstanceDescription(),
+		"seeded an event",
+		mt.String()+mt.Kind().Symbol(),
+		f.EventEnvelope.Message.MessageDescription(),
+	)
+}
+
 // messageLoggedByAggregate returns the log message for f.
 func (l *Logger) messageLoggedByAggregate(f MessageLoggedByAggregate) {
 	l.log(
@@ -495,6 +627,81 @@ func (l *Logger) projectionCompactionCompleted(f ProjectionCompactionCompleted)
 	}
 }
 
+// projectionResetCompleted returns the log message for f.
+func (l *Logger) projectionResetCompleted(f ProjectionResetCompleted) {
+	if f.Error == nil {
+		l.log(
+			nil,
+			[]logging.Icon{
+				"",
+				logging.ProjectionIcon,
+				"",
+			},
+			f.Handler.Identity().GetName(),
+			"reset",
+		)
+	} else {
+		l.log(
+			nil,
+			[]logging.Icon{
+				"",
+				logging.ProjectionIcon,
+				logging.ErrorIcon,
+			},
+			f.Handler.Identity().GetName(),
+			fmt.Sprintf("reset failed: %s", f.Error),
+		)
+	}
+}
+
+// projectionEventAlreadyHandled returns the log message for f.
+func (l *Logger) projectionEventAlreadyHandled(f ProjectionEventAlreadyHandled) {
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.InboundIcon,
+			logging.ProjectionIcon,
+			"",
+		},
+		f.Handler.Identity().GetName(),
+		fmt.Sprintf(
+			"event ignored because it has already been applied, checkpoint offset is %d",
+			f.CheckpointOffset,
+		),
+	)
+}
+
+// projectionCrashSimulated returns the log message for f.
+func (l *Logger) projectionCrashSimulated(f ProjectionCrashSimulated) {
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.RetryIcon,
+			logging.ProjectionIcon,
+			"",
+		},
+		f.Handler.Identity().GetName(),
+		"simulated a crash before the result of handling the event was recorded, redelivering",
+	)
+}
+
+// projectionStaleCheckpointDelivered returns the log message for f.
+func (l *Logger) projectionStaleCheckpointDelivered(f ProjectionStaleCheckpointDelivered) {
+	l.log(
+		f.Envelope,
+		[]logging.Icon{
+			logging.RetryIcon,
+			logging.ProjectionIcon,
+			"",
+		},
+		f.Handler.Identity().GetName(),
+		fmt.Sprintf(
+			"redelivering event with stale checkpoint offset %d",
+			f.CheckpointOffset,
+		),
+	)
+}
+
 // messageLoggedByProjection returns the log message for f.
 func (l *Logger) messageLoggedByProjection(f MessageLoggedByProjection) {
 	icons := []logging.Icon{
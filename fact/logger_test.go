@@ -169,6 +169,11 @@ func TestLogger(t *testing.T) {
 					Message: `= 10  ∵ 10  ⋲ 10  ↻ ⚙    *stubs.CommandStub[TypeA]? ● command ignored because it's idempotency key "<key>" has already been used`,
 					Fact:    CommandDeduplicated{Envelope: command, Key: "<key>"},
 				},
+				{
+					Name:    "DispatchOrderRandomized",
+					Message: "= --  ∵ --  ⋲ --    ⚙    dispatch order randomized using seed 123",
+					Fact:    DispatchOrderRandomized{Seed: 123},
+				},
 				{
 					Name:    "DispatchCompleted (success)",
 					Message: "",
@@ -194,6 +199,31 @@ func TestLogger(t *testing.T) {
 					Message: "= 10  ∵ 10  ⋲ 10  ▽ ∴ ✖  <aggregate> ● <error>",
 					Fact:    HandlingCompleted{Handler: aggregate, Envelope: command, Error: errors.New("<error>")},
 				},
+				{
+					Name:    "FaultInjected (error)",
+					Message: "= 10  ∵ 10  ⋲ 10  ▽ ∴ ✖  <aggregate> ● fault injected, returning error: <error>",
+					Fact:    FaultInjected{Handler: aggregate, Envelope: command, Error: errors.New("<error>")},
+				},
+				{
+					Name:    "FaultInjected (panic)",
+					Message: "= 10  ∵ 10  ⋲ 10  ▽ ∴ ✖  <aggregate> ● fault injected, panicking with <value>",
+					Fact:    FaultInjected{Handler: aggregate, Envelope: command, PanicValue: "<value>"},
+				},
+				{
+					Name:    "RetryScheduled",
+					Message: "= 10  ∵ 10  ⋲ 10  ↻ ∴    <aggregate> ● attempt #2 scheduled for 2006-01-02T15:04:05Z",
+					Fact:    RetryScheduled{Handler: aggregate, Envelope: command, Error: errors.New("<error>"), Attempt: 2, RetryAt: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+				},
+				{
+					Name:    "MessageDeadLettered",
+					Message: "= 10  ∵ 10  ⋲ 10  ▽ ∴ ✖  <aggregate> ● message dead-lettered after 3 attempt(s)",
+					Fact:    MessageDeadLettered{Handler: aggregate, Envelope: command, Error: errors.New("<error>"), Attempts: 3},
+				},
+				{
+					Name:    "SlowHandlerDetected",
+					Message: "= 10  ∵ 10  ⋲ 10  ▼ ∴    <aggregate> ● slow handling took 1.5s, exceeding the threshold of 1s",
+					Fact:    SlowHandlerDetected{Handler: aggregate, Envelope: command, Duration: 1500 * time.Millisecond, Threshold: time.Second},
+				},
 				{
 					Name:    "HandlingSkipped (handler type)",
 					Message: "= 10  ∵ 10  ⋲ 10  ▼ ∴    <aggregate> ● handler skipped because aggregate handlers are disabled",
@@ -254,6 +284,11 @@ func TestLogger(t *testing.T) {
 					Message: "= --  ∵ --  ⋲ --    ∴ ✖  <aggregate> ● <error>",
 					Fact:    TickCompleted{Handler: aggregate, Error: errors.New("<error>")},
 				},
+				{
+					Name:    "SlowHandlerDetected",
+					Message: "= --  ∵ --  ⋲ --    ∴    <aggregate> ● slow tick took 1.5s, exceeding the threshold of 1s",
+					Fact:    SlowHandlerDetected{Handler: aggregate, Duration: 1500 * time.Millisecond, Threshold: time.Second},
+				},
 			})
 		})
 
@@ -320,6 +355,23 @@ func TestLogger(t *testing.T) {
 						),
 					},
 				},
+				{
+					Name:    "EventSeededIntoAggregate",
+					Message: "= 20  ∵ 10  ⋲ 10  ▲ ∴    <aggregate> <instance> ● <description> ● seeded an event ● *stubs.EventStub[TypeA]! ● event(stubs.TypeA:A1, valid)",
+					Fact: EventSeededIntoAggregate{
+						Handler:    aggregate,
+						InstanceID: "<instance>",
+						Root:       aggregateWithDescription,
+						EventEnvelope: command.NewEvent(
+							"20",
+							EventA1,
+							time.Now(),
+							envelope.Origin{},
+							"a4dea2c6-6499-441c-94ad-686334880c1c",
+							42,
+						),
+					},
+				},
 				{
 					Name:    "MessageLoggedByAggregate",
 					Message: "= 10  ∵ 10  ⋲ 10  ▼ ∴    <aggregate> <instance> ● <message>",
@@ -516,6 +568,44 @@ func TestLogger(t *testing.T) {
 					Message: "= --  ∵ --  ⋲ --    Σ ✖  <projection> ● compaction failed: <error>",
 					Fact:    ProjectionCompactionCompleted{Handler: projection, Error: errors.New("<error>")},
 				},
+				{
+					Name:    "ProjectionResetBegun",
+					Message: "",
+					Fact:    ProjectionResetBegun{},
+				},
+				{
+					Name:    "ProjectionResetCompleted (success)",
+					Message: "= --  ∵ --  ⋲ --    Σ    <projection> ● reset",
+					Fact:    ProjectionResetCompleted{Handler: projection},
+				},
+				{
+					Name:    "ProjectionResetCompleted (failure)",
+					Message: "= --  ∵ --  ⋲ --    Σ ✖  <projection> ● reset failed: <error>",
+					Fact:    ProjectionResetCompleted{Handler: projection, Error: errors.New("<error>")},
+				},
+				{
+					Name:    "ProjectionEventAlreadyHandled",
+					Message: "= 10  ∵ 10  ⋲ 10  ▼ Σ    <projection> ● event ignored because it has already been applied, checkpoint offset is 2",
+					Fact: ProjectionEventAlreadyHandled{
+						Handler:          projection,
+						Envelope:         command,
+						CheckpointOffset: 2,
+					},
+				},
+				{
+					Name:    "ProjectionCrashSimulated",
+					Message: "= 10  ∵ 10  ⋲ 10  ↻ Σ    <projection> ● simulated a crash before the result of handling the event was recorded, redelivering",
+					Fact:    ProjectionCrashSimulated{Handler: projection, Envelope: command},
+				},
+				{
+					Name:    "ProjectionStaleCheckpointDelivered",
+					Message: "= 10  ∵ 10  ⋲ 10  ↻ Σ    <projection> ● redelivering event with stale checkpoint offset 2",
+					Fact: ProjectionStaleCheckpointDelivered{
+						Handler:          projection,
+						Envelope:         command,
+						CheckpointOffset: 2,
+					},
+				},
 				{
 					Name:    "MessageLoggedByProjection",
 					Message: "= 10  ∵ 10  ⋲ 10  ▼ Σ    <projection> ● <message>",
@@ -18,13 +18,55 @@ type ProjectionCompactionCompleted struct {
 	Error   error
 }
 
+// ProjectionResetBegun indicates that a projection is about to be reset.
+type ProjectionResetBegun struct {
+	Handler *config.Projection
+}
+
+// ProjectionResetCompleted indicates that a projection has been reset, either
+// successfully or unsuccessfully.
+type ProjectionResetCompleted struct {
+	Handler *config.Projection
+	Error   error
+}
+
 // MessageLoggedByProjection indicates that a projection wrote a log message
-// while handling an event or compacting the projection.
+// while handling an event, or compacting or resetting the projection.
 //
-// Envelope is nil if the message was logged during compaction.
+// Envelope is nil if the message was logged during compaction or reset.
 type MessageLoggedByProjection struct {
 	Handler      *config.Projection
 	Envelope     *envelope.Envelope
 	LogFormat    string
 	LogArguments []any
 }
+
+// ProjectionEventAlreadyHandled indicates that a projection did not handle an
+// event because its checkpoint offset shows that the event has already been
+// applied.
+type ProjectionEventAlreadyHandled struct {
+	Handler          *config.Projection
+	Envelope         *envelope.Envelope
+	CheckpointOffset uint64
+}
+
+// ProjectionCrashSimulated indicates that the engine discarded the result of a
+// projection's HandleEvent() method, as though the engine crashed before it
+// could record the result, and that the event is about to be delivered again.
+//
+// It is caused by the engine.CrashProjection() operation option.
+type ProjectionCrashSimulated struct {
+	Handler  *config.Projection
+	Envelope *envelope.Envelope
+}
+
+// ProjectionStaleCheckpointDelivered indicates that an event is about to be
+// delivered to a projection with a stale checkpoint offset, that is, one that
+// does not match the projection's actual checkpoint offset.
+//
+// It is caused by the engine.StaleProjectionCheckpoint() operation option.
+type ProjectionStaleCheckpointDelivered struct {
+	Handler          *config.Projection
+	Envelope         *envelope.Envelope
+	CheckpointOffset uint64
+}
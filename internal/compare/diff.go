@@ -0,0 +1,88 @@
+package compare
+
+import (
+	"reflect"
+
+	"github.com/dogmatiq/testkit/internal/compare/internal/unsafereflect"
+	"google.golang.org/protobuf/proto"
+)
+
+// FieldDiff describes a struct field that differs between two values.
+type FieldDiff struct {
+	// Path is the path to the field within the compared values, such as
+	// ".Foo.Bar". It is empty if the values themselves differ, and are not
+	// structs.
+	Path string
+
+	// A and B are the values of the field within a and b, respectively.
+	A, B any
+}
+
+// Diff returns the struct fields that differ between a and b, according to the
+// same semantics as [Equal].
+//
+// Pointers, interfaces and nested structs are descended into, such that each
+// [FieldDiff] describes the most deeply nested field that differs. Other
+// values, including slices, maps and [proto.Message] values, are reported as a
+// whole. It returns nil if a and b are equal.
+func Diff(a, b any) []FieldDiff {
+	var diffs []FieldDiff
+
+	diff(
+		reflect.ValueOf(a),
+		reflect.ValueOf(b),
+		"",
+		&diffs,
+	)
+
+	return diffs
+}
+
+func diff(a, b reflect.Value, path string, diffs *[]FieldDiff) {
+	va, vb := valueOf(a), valueOf(b)
+
+	if Equal(va, vb) {
+		return
+	}
+
+	if a.IsValid() && b.IsValid() && a.Type() == b.Type() {
+		switch a.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if !a.IsNil() && !b.IsNil() && !isProto(va) {
+				diff(a.Elem(), b.Elem(), path, diffs)
+				return
+			}
+
+		case reflect.Struct:
+			for i := range a.NumField() {
+				fa := a.Field(i)
+				fb := b.Field(i)
+
+				if !fa.CanInterface() {
+					fa = unsafereflect.MakeMutable(fa)
+					fb = unsafereflect.MakeMutable(fb)
+				}
+
+				diff(fa, fb, path+"."+a.Type().Field(i).Name, diffs)
+			}
+			return
+		}
+	}
+
+	*diffs = append(*diffs, FieldDiff{path, va, vb})
+}
+
+// isProto returns true if v is a [proto.Message].
+func isProto(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+// valueOf returns the value contained in v, or nil if v is the zero
+// [reflect.Value].
+func valueOf(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,49 @@
+package compare_test
+
+import (
+	"testing"
+
+	. "github.com/dogmatiq/testkit/internal/compare"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDiff(t *testing.T) {
+	factory := func() func() { return func() {} }
+	fn1 := factory()
+	fn2 := factory()
+
+	cases := []struct {
+		Name string
+		A, B any
+		Want []FieldDiff
+	}{
+		{"equal values", &ExampleType{Value: "x", CallFunc: fn1}, &ExampleType{Value: "x", CallFunc: fn2}, nil},
+		{"non-struct values", "foo", "bar", []FieldDiff{{"", "foo", "bar"}}},
+		{"nil vs non-nil", nil, "x", []FieldDiff{{"", nil, "x"}}},
+		{"struct fields", &ExampleType{Value: "x"}, &ExampleType{Value: "y"}, []FieldDiff{{".Value", "x", "y"}}},
+		{"nested struct fields", nestedFuncType{Inner: ExampleType{Value: "x"}}, nestedFuncType{Inner: ExampleType{Value: "y"}}, []FieldDiff{{".Inner.Value", "x", "y"}}},
+		{"unexported fields", unexportedFieldType{value: "x"}, unexportedFieldType{value: "y"}, []FieldDiff{{".value", "x", "y"}}},
+		{"multiple fields", nestedFuncType{Value: "a", Inner: ExampleType{Value: "x"}}, nestedFuncType{Value: "b", Inner: ExampleType{Value: "y"}}, []FieldDiff{{".Value", "a", "b"}, {".Inner.Value", "x", "y"}}},
+		{"slice fields", struct{ Values []string }{[]string{"a"}}, struct{ Values []string }{[]string{"b"}}, []FieldDiff{{".Values", []string{"a"}, []string{"b"}}}},
+		{"proto messages", wrapperspb.String("foo"), wrapperspb.String("bar"), []FieldDiff{{"", wrapperspb.String("foo"), wrapperspb.String("bar")}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got := Diff(c.A, c.B)
+
+			if len(got) != len(c.Want) {
+				t.Fatalf("Diff() returned %d diffs, want %d: %v", len(got), len(c.Want), got)
+			}
+
+			for i, d := range got {
+				xtesting.Expect(t, "unexpected path", d.Path, c.Want[i].Path)
+
+				if !Equal(d.A, c.Want[i].A) || !Equal(d.B, c.Want[i].B) {
+					t.Fatalf("unexpected values at %q: got %v and %v, want %v and %v", d.Path, d.A, d.B, c.Want[i].A, c.Want[i].B)
+				}
+			}
+		})
+	}
+}
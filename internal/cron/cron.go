@@ -0,0 +1,252 @@
+// Package cron parses cron expressions and finds the wall-clock times that
+// match them.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar are true if the day-of-month and day-of-week fields
+	// begin with an asterisk. If neither does, a day matches if it matches
+	// either field, otherwise it must match both.
+	domStar, dowStar bool
+}
+
+// field describes the range of values in one field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day-of-month", min: 1, max: 31}
+	monthField  = field{
+		name: "month", min: 1, max: 12,
+		names: []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"},
+	}
+	dowField = field{
+		name: "day-of-week", min: 0, max: 7,
+		names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"},
+	}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard five-field cron expression.
+//
+// The fields are minute, hour, day-of-month, month and day-of-week. Each field
+// is a comma-separated list of values, ranges (a-b) or asterisks, optionally
+// followed by a step (/n). Months and days of the week may be given by their
+// three-letter English names. Sunday is either 0 or 7.
+//
+// The @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly
+// macros are also supported.
+func Parse(expr string) (Schedule, error) {
+	if m, ok := macros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var s Schedule
+	var err error
+
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return Schedule{}, err
+	}
+
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return Schedule{}, err
+	}
+
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return Schedule{}, err
+	}
+
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return Schedule{}, err
+	}
+
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return Schedule{}, err
+	}
+
+	// Sunday may be given as either 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+		s.dow &^= 1 << 7
+	}
+
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	if !s.canMatch() {
+		return Schedule{}, errors.New("the day-of-month never occurs in any of the months")
+	}
+
+	return s, nil
+}
+
+// Next returns the earliest wall-clock time after w that matches the schedule.
+//
+// w is a wall-clock time represented in UTC, such that it is not affected by
+// daylight saving transitions. The returned time is also in UTC.
+func (s Schedule) Next(w time.Time) time.Time {
+	t := w.Truncate(time.Minute).Add(time.Minute)
+
+	for {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		} else if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		} else if !has(s.hour, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		} else if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+}
+
+// matchesDay returns true if the date of t matches the day-of-month and
+// day-of-week fields.
+func (s Schedule) matchesDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// canMatch returns true if the schedule matches at least one date.
+//
+// Every valid combination of month and day-of-month falls on each day of the
+// week at some point, so the only schedules that never match are those that
+// restrict the day-of-month to days that do not occur in the selected months.
+func (s Schedule) canMatch() bool {
+	if !s.domStar && !s.dowStar {
+		return true
+	}
+
+	// The number of days in each month, allowing for leap years.
+	days := [...]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+	for m := 1; m <= 12; m++ {
+		if has(s.month, m) && bits.TrailingZeros64(s.dom) <= days[m] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parse parses a single field of a cron expression into a bit set of the
+// values that it matches.
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+
+	for item := range strings.SplitSeq(expr, ",") {
+		first, last, step, err := f.parseItem(item)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s field (%q): %w", f.name, expr, err)
+		}
+
+		for v := first; v <= last; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+// parseItem parses a single item within a comma-separated field.
+func (f field) parseItem(item string) (first, last, step int, err error) {
+	rng, stepText, hasStep := strings.Cut(item, "/")
+	step = 1
+
+	if hasStep {
+		step, err = strconv.Atoi(stepText)
+		if err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step (%q)", stepText)
+		}
+	}
+
+	if rng == "*" {
+		return f.min, f.max, step, nil
+	}
+
+	lo, hi, isRange := strings.Cut(rng, "-")
+
+	if first, err = f.parseValue(lo); err != nil {
+		return 0, 0, 0, err
+	}
+
+	switch {
+	case isRange:
+		if last, err = f.parseValue(hi); err != nil {
+			return 0, 0, 0, err
+		}
+
+		if last < first {
+			return 0, 0, 0, fmt.Errorf("invalid range (%q)", rng)
+		}
+	case hasStep:
+		last = f.max
+	default:
+		last = first
+	}
+
+	return first, last, step, nil
+}
+
+// parseValue parses a single numeric or named value.
+func (f field) parseValue(text string) (int, error) {
+	for v, n := range f.names {
+		if n != "" && strings.EqualFold(text, n) {
+			return v, nil
+		}
+	}
+
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value (%q)", text)
+	}
+
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value (%d) is out of range, expected %d-%d", v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// has returns true if v is a member of set.
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
@@ -0,0 +1,83 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/dogmatiq/testkit/internal/cron"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestSchedule(t *testing.T) {
+	t.Run("func Next()", func(t *testing.T) {
+		// 2001-02-03 is a Saturday.
+		start := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+		cases := []struct {
+			Expr string
+			Want time.Time
+		}{
+			{"* * * * *", time.Date(2001, 2, 3, 4, 6, 0, 0, time.UTC)},
+			{"*/15 * * * *", time.Date(2001, 2, 3, 4, 15, 0, 0, time.UTC)},
+			{"0 9 * * *", time.Date(2001, 2, 3, 9, 0, 0, 0, time.UTC)},
+			{"0 3 * * *", time.Date(2001, 2, 4, 3, 0, 0, 0, time.UTC)},
+			{"30 9 * * MON-FRI", time.Date(2001, 2, 5, 9, 30, 0, 0, time.UTC)},
+			{"0 0 * * 7", time.Date(2001, 2, 4, 0, 0, 0, 0, time.UTC)},
+			{"0 0 1 * *", time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{"0 0 31 * *", time.Date(2001, 3, 31, 0, 0, 0, 0, time.UTC)},
+			{"0 0 29 feb *", time.Date(2004, 2, 29, 0, 0, 0, 0, time.UTC)},
+			{"0 0 15 * MON", time.Date(2001, 2, 5, 0, 0, 0, 0, time.UTC)},
+			{"0 0 1,15 6-8 *", time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)},
+			{"5/20 4 * * *", time.Date(2001, 2, 3, 4, 25, 0, 0, time.UTC)},
+			{"@monthly", time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{"@hourly", time.Date(2001, 2, 3, 5, 0, 0, 0, time.UTC)},
+		}
+
+		for _, c := range cases {
+			t.Run(c.Expr, func(t *testing.T) {
+				s, err := Parse(c.Expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				xtesting.Expect(t, "unexpected time", s.Next(start), c.Want)
+			})
+		}
+
+		t.Run("it returns a time strictly after the given time", func(t *testing.T) {
+			s, err := Parse("0 9 * * *")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := time.Date(2001, 2, 3, 9, 0, 0, 0, time.UTC)
+			xtesting.Expect(t, "unexpected time", s.Next(w), w.AddDate(0, 0, 1))
+		})
+	})
+
+	t.Run("func Parse()", func(t *testing.T) {
+		cases := []struct {
+			Expr  string
+			Error string
+		}{
+			{"* * * *", "expected 5 fields, got 4"},
+			{"60 * * * *", `invalid minute field ("60"): value (60) is out of range, expected 0-59`},
+			{"* * 0 * *", `invalid day-of-month field ("0"): value (0) is out of range, expected 1-31`},
+			{"* * * XYZ *", `invalid month field ("XYZ"): invalid value ("XYZ")`},
+			{"* 5-1 * * *", `invalid hour field ("5-1"): invalid range ("5-1")`},
+			{"*/0 * * * *", `invalid minute field ("*/0"): invalid step ("0")`},
+			{"0 0 30 2 *", "the day-of-month never occurs in any of the months"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.Expr, func(t *testing.T) {
+				_, err := Parse(c.Expr)
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				xtesting.Expect(t, "unexpected error", err.Error(), c.Error)
+			})
+		}
+	})
+}
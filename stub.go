@@ -0,0 +1,124 @@
+package testkit
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
+	"github.com/dogmatiq/enginekit/optional"
+)
+
+// StubHandler returns a test option that replaces the implementation of the
+// handler with the given name with impl for the duration of the test.
+//
+// The handler retains the identity, routes and other configuration from the
+// application; impl's Configure() method is never called. This allows a
+// handler that has side-effects outside of the application, such as an
+// integration that calls a third-party API, to be replaced by a stub that
+// produces canned messages.
+//
+// impl must implement the same handler interface as the handler it replaces.
+// An aggregate or process implementation with a specific root type must be
+// wrapped by dogma.UntypedAggregateMessageHandler() or
+// dogma.UntypedProcessMessageHandler().
+func StubHandler(name string, impl any) TestOption {
+	if impl == nil {
+		panic(fmt.Sprintf("StubHandler(%q, <nil>): implementation must not be nil", name))
+	}
+
+	return testOptionFunc(func(t *Test) {
+		t.app = stubHandler(t.app, name, impl)
+	})
+}
+
+// stubHandler returns a copy of app in which the implementation of the handler
+// with the given name is replaced with impl.
+func stubHandler(
+	app *config.Application,
+	name string,
+	impl any,
+) *config.Application {
+	handlers := slices.Clone(app.Handlers())
+
+	i := slices.IndexFunc(
+		handlers,
+		func(h config.Handler) bool {
+			return h.Identity().GetName() == name
+		},
+	)
+
+	if i == -1 {
+		panic(fmt.Sprintf(
+			"the %q application does not have a handler named %q",
+			app.Identity().GetName(),
+			name,
+		))
+	}
+
+	switch h := handlers[i].(type) {
+	case *config.Aggregate:
+		stub := *h
+		stub.Source = optional.Some(stubImplementation[dogma.AggregateMessageHandler[dogma.AggregateRoot]](h, "AggregateMessageHandler", impl))
+		stub.TypeName = optional.Some(implementationTypeName(impl))
+		handlers[i] = &stub
+	case *config.Process:
+		stub := *h
+		stub.Source = optional.Some(stubImplementation[dogma.ProcessMessageHandler[dogma.ProcessRoot]](h, "ProcessMessageHandler", impl))
+		stub.TypeName = optional.Some(implementationTypeName(impl))
+		handlers[i] = &stub
+	case *config.Integration:
+		stub := *h
+		stub.Source = optional.Some(stubImplementation[dogma.IntegrationMessageHandler](h, "IntegrationMessageHandler", impl))
+		stub.TypeName = optional.Some(implementationTypeName(impl))
+		handlers[i] = &stub
+	case *config.Projection:
+		stub := *h
+		stub.Source = optional.Some(stubImplementation[dogma.ProjectionMessageHandler](h, "ProjectionMessageHandler", impl))
+		stub.TypeName = optional.Some(implementationTypeName(impl))
+		handlers[i] = &stub
+	}
+
+	stubbed := *app
+	stubbed.HandlerComponents = handlers
+
+	return &stubbed
+}
+
+// stubImplementation returns impl as a T, or panics if it does not implement
+// the interface of the handler it replaces.
+func stubImplementation[T any](
+	h config.Handler,
+	iface string,
+	impl any,
+) T {
+	if x, ok := impl.(T); ok {
+		return x
+	}
+
+	panic(fmt.Sprintf(
+		"cannot stub the %q handler, %T does not implement dogma.%s",
+		h.Identity().GetName(),
+		impl,
+		iface,
+	))
+}
+
+// implementationTypeName returns the fully-qualified name of the type that
+// implements a handler, in the form used by the handler's configuration.
+func implementationTypeName(impl any) string {
+	t := reflect.TypeOf(dogma.UnwrapHandler(impl))
+
+	var prefix string
+	for t.Name() == "" && t.Kind() == reflect.Pointer {
+		prefix += "*"
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return prefix + t.String()
+	}
+
+	return prefix + t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,163 @@
+package testkit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/internal/testingmock"
+	"github.com/dogmatiq/testkit/internal/x/xtesting"
+)
+
+func TestStubHandler(t *testing.T) {
+	newApp := func() (dogma.Application, *AggregateMessageHandlerStub[*AggregateRootStub]) {
+		aggregate := &AggregateMessageHandlerStub[*AggregateRootStub]{
+			ConfigureFunc: func(c dogma.AggregateConfigurer) {
+				c.Identity("<aggregate>", "d2d5e1ea-6bd6-4f0e-b1a6-6b0f0a7c9d8e")
+				c.Routes(
+					dogma.HandlesCommand[*CommandStub[TypeB]](),
+					dogma.RecordsEvent[*EventStub[TypeB]](),
+				)
+			},
+			RouteCommandToInstanceFunc: func(dogma.Command) string {
+				return "<instance>"
+			},
+		}
+
+		integration := &IntegrationMessageHandlerStub{
+			ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+				c.Identity("<integration>", "38c5e6c4-5d21-4b7e-9a8a-7d0e1d35a6f2")
+				c.Routes(
+					dogma.HandlesCommand[*CommandStub[TypeA]](),
+					dogma.RecordsEvent[*EventStub[TypeA]](),
+				)
+			},
+			HandleCommandFunc: func(
+				context.Context,
+				dogma.IntegrationCommandScope,
+				dogma.Command,
+			) error {
+				t.Fatal("unexpected call to the real implementation")
+				return nil
+			},
+		}
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "1b8e0f5c-0f83-4b5e-8a5e-3d5c2e0c1f9a")
+				c.Routes(
+					dogma.ViaAggregate(aggregate),
+					dogma.ViaIntegration(integration),
+				)
+			},
+		}
+
+		return app, aggregate
+	}
+
+	t.Run("it replaces the implementation of the handler", func(t *testing.T) {
+		app, _ := newApp()
+
+		// The stub does not configure an identity or routes, the handler's
+		// existing configuration is used instead.
+		stub := &IntegrationMessageHandlerStub{
+			HandleCommandFunc: func(
+				_ context.Context,
+				s dogma.IntegrationCommandScope,
+				_ dogma.Command,
+			) error {
+				s.RecordEvent(EventA1)
+				return nil
+			},
+		}
+
+		Begin(
+			t,
+			app,
+			StubHandler("<integration>", stub),
+		).
+			EnableHandlers("<integration>").
+			Expect(
+				ExecuteCommand(CommandA1),
+				ToRecordEvent(EventA1),
+			)
+	})
+
+	t.Run("it replaces aggregate implementations", func(t *testing.T) {
+		app, aggregate := newApp()
+		aggregate.HandleCommandFunc = func(
+			*AggregateRootStub,
+			dogma.AggregateCommandScope[*AggregateRootStub],
+			dogma.Command,
+		) {
+			t.Fatal("unexpected call to the real implementation")
+		}
+
+		stub := &AggregateMessageHandlerStub[*AggregateRootStub]{
+			RouteCommandToInstanceFunc: func(dogma.Command) string {
+				return "<instance>"
+			},
+			HandleCommandFunc: func(
+				_ *AggregateRootStub,
+				s dogma.AggregateCommandScope[*AggregateRootStub],
+				_ dogma.Command,
+			) {
+				s.RecordEvent(EventB1)
+			},
+		}
+
+		Begin(
+			t,
+			app,
+			StubHandler("<aggregate>", dogma.UntypedAggregateMessageHandler(stub)),
+		).
+			Expect(
+				ExecuteCommand(CommandB1),
+				ToRecordEvent(EventB1),
+			)
+	})
+
+	t.Run("it panics if the handler is not recognized", func(t *testing.T) {
+		app, _ := newApp()
+
+		xtesting.ExpectPanic(
+			t,
+			`the "<app>" application does not have a handler named "<unknown>"`,
+			func() {
+				Begin(
+					&testingmock.T{},
+					app,
+					StubHandler("<unknown>", &IntegrationMessageHandlerStub{}),
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if the implementation is a different type of handler", func(t *testing.T) {
+		app, _ := newApp()
+
+		xtesting.ExpectPanic(
+			t,
+			`cannot stub the "<integration>" handler, *stubs.ProjectionMessageHandlerStub does not implement dogma.IntegrationMessageHandler`,
+			func() {
+				Begin(
+					&testingmock.T{},
+					app,
+					StubHandler("<integration>", &ProjectionMessageHandlerStub{}),
+				)
+			},
+		)
+	})
+
+	t.Run("it panics if the implementation is nil", func(t *testing.T) {
+		xtesting.ExpectPanic(
+			t,
+			`StubHandler("<integration>", <nil>): implementation must not be nil`,
+			func() {
+				StubHandler("<integration>", nil)
+			},
+		)
+	})
+}
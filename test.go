@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,10 +28,15 @@ type Test struct {
 	app              *config.Application
 	virtualClock     time.Time
 	engine           *engine.Engine
+	engineOptions    []engine.Option
 	executor         CommandExecutor
 	predicateOptions PredicateOptions
 	operationOptions []engine.OperationOption
 	annotations      []Annotation
+
+	// failOnSlowHandlers is true if the test fails when the engine records a
+	// fact.SlowHandlerDetected fact.
+	failOnSlowHandlers bool
 }
 
 // Begin starts a new test.
@@ -61,18 +67,12 @@ func BeginContext(
 		testingT:     t,
 		app:          cfg,
 		virtualClock: time.Now(),
-		engine: engine.MustNew(
-			cfg,
+		engineOptions: []engine.Option{
 			engine.EnableProjectionCompactionDuringHandling(true),
-		),
+		},
 		operationOptions: []engine.OperationOption{
 			engine.EnableProjections(false),
 			engine.EnableIntegrations(false),
-			engine.WithObserver(
-				fact.NewLogger(func(s string) {
-					log(t, s)
-				}),
-			),
 		},
 	}
 
@@ -80,6 +80,46 @@ func BeginContext(
 		opt.applyTestOption(test)
 	}
 
+	test.engine = engine.MustNew(test.app, test.engineOptions...)
+
+	return test
+}
+
+// Fork returns a new test that begins in the same state as t.
+//
+// The new test has its own copy of the engine state, virtual clock, annotations,
+// enabled handlers and injected faults, such that actions performed on one test
+// have no effect on the other. Its log output and failures are reported via ft.
+//
+// It is typically used to make several independent expectations after a shared
+// call to Prepare(), with each fork being run in its own subtest.
+//
+// State that is managed by the handler implementations themselves, such as
+// projection data, is shared between t and the new test.
+func (t *Test) Fork(ft TestingT) *Test {
+	e, err := t.engine.Fork()
+	if err != nil {
+		panic(err)
+	}
+
+	test := &Test{
+		ctx:              t.ctx,
+		testingT:         ft,
+		app:              t.app,
+		virtualClock:     t.virtualClock,
+		engine:           e,
+		engineOptions:    t.engineOptions,
+		predicateOptions: t.predicateOptions,
+		operationOptions: engine.ForkOperationOptions(t.operationOptions),
+		annotations:      slices.Clone(t.annotations),
+
+		failOnSlowHandlers: t.failOnSlowHandlers,
+	}
+
+	t.executor.m.RLock()
+	test.executor.interceptor = t.executor.interceptor
+	t.executor.m.RUnlock()
+
 	return test
 }
 
@@ -187,6 +227,62 @@ func (t *Test) CommandExecutor() dogma.CommandExecutor {
 	return &t.executor
 }
 
+// AggregateRoot returns the root of an aggregate instance, rebuilt from the
+// events that the instance has recorded.
+//
+// ok is false if the instance has not recorded any events.
+//
+// It panics if the application does not have an aggregate with the given name.
+func (t *Test) AggregateRoot(handler, id string) (r dogma.AggregateRoot, ok bool) {
+	return t.engine.AggregateRoot(handler, id)
+}
+
+// AggregateInstanceIDs returns the IDs of the instances of the named aggregate
+// that have recorded at least one event, in lexical order.
+//
+// It panics if the application does not have an aggregate with the given name.
+func (t *Test) AggregateInstanceIDs(handler string) []string {
+	return t.engine.AggregateInstanceIDs(handler)
+}
+
+// ProcessRoot returns the root of a process instance.
+//
+// ok is false if the instance has never begun. ended is true if the instance
+// has ended.
+//
+// It panics if the application does not have a process with the given name.
+func (t *Test) ProcessRoot(handler, id string) (r dogma.ProcessRoot, ended, ok bool) {
+	return t.engine.ProcessRoot(handler, id)
+}
+
+// ProcessInstanceIDs returns the IDs of the instances of the named process that
+// have begun, including those that have since ended, in lexical order.
+//
+// It panics if the application does not have a process with the given name.
+func (t *Test) ProcessInstanceIDs(handler string) []string {
+	return t.engine.ProcessInstanceIDs(handler)
+}
+
+// DeadLetters returns the messages that handlers have failed to handle, in the
+// order they were dead-lettered.
+//
+// See engine.Engine.DeadLetters() and the RetryDeadLetters() action.
+func (t *Test) DeadLetters() []engine.DeadLetter {
+	return t.engine.DeadLetters()
+}
+
+// PendingDeadlines returns the deadline messages that processes have scheduled
+// but that have not yet been handled, in the order they are scheduled for.
+//
+// Deadlines scheduled by processes that are disabled within the test are not
+// returned.
+//
+// See engine.Engine.PendingDeadlines(), ToNextDeadline() and
+// ThroughAllDeadlines().
+func (t *Test) PendingDeadlines() []engine.PendingDeadline {
+	return t.engine.PendingDeadlines(t.operationOptions...)
+}
+
 // Annotate adds an annotation to v.
 //
 // The annotation text is displayed whenever v is rendered in a test report.
@@ -301,13 +397,34 @@ func (t *Test) enableHandlersLike(patterns []string, enable bool) *Test {
 
 // doAction calls act.Do() with a scope appropriate for this test.
 func (t *Test) doAction(act Action, options ...engine.OperationOption) error {
+	var slow []fact.SlowHandlerDetected
+
 	opts := []engine.OperationOption{
 		engine.WithCurrentTime(t.virtualClock),
+		engine.WithObserver(
+			fact.NewLogger(func(s string) {
+				log(t.testingT, s)
+			}),
+		),
 	}
+
+	if t.failOnSlowHandlers {
+		opts = append(
+			opts,
+			engine.WithObserver(
+				fact.ObserverFunc(func(f fact.Fact) {
+					if f, ok := f.(fact.SlowHandlerDetected); ok {
+						slow = append(slow, f)
+					}
+				}),
+			),
+		)
+	}
+
 	opts = append(opts, t.operationOptions...)
 	opts = append(opts, options...)
 
-	return act.Do(
+	if err := act.Do(
 		t.ctx,
 		ActionScope{
 			App:              t.app,
@@ -316,5 +433,26 @@ func (t *Test) doAction(act Action, options ...engine.OperationOption) error {
 			Executor:         &t.executor,
 			OperationOptions: opts,
 		},
-	)
+	); err != nil {
+		return err
+	}
+
+	if len(slow) != 0 {
+		f := slow[0]
+		call := "handling"
+		if f.Envelope == nil {
+			call = "tick"
+		}
+
+		return fmt.Errorf(
+			"%s %s: slow %s took %s, exceeding the threshold of %s",
+			f.Handler.Identity().GetName(),
+			f.Handler.HandlerType(),
+			call,
+			f.Duration,
+			f.Threshold,
+		)
+	}
+
+	return nil
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/dogmatiq/dogma"
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/engine"
 	"github.com/dogmatiq/testkit/internal/testingmock"
 	"github.com/dogmatiq/testkit/internal/x/xtesting"
 )
@@ -380,3 +382,260 @@ func TestTest_Annotate(t *testing.T) {
 		)(mt)
 	})
 }
+
+func TestTest_AggregateRoot(t *testing.T) {
+	t.Run("it returns the root of the aggregate instance", func(t *testing.T) {
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "0e8a1d8c-7b0e-4f43-9d8e-4c2a0f2b3e11")
+				c.Routes(
+					dogma.ViaAggregate(&AggregateMessageHandlerStub[*AggregateRootStub]{
+						ConfigureFunc: func(c dogma.AggregateConfigurer) {
+							c.Identity("<aggregate>", "3b0f5d8e-2a2b-4d6c-9f4a-6d1e7c8b9a01")
+							c.Routes(
+								dogma.HandlesCommand[*CommandStub[TypeA]](),
+								dogma.RecordsEvent[*EventStub[TypeA]](),
+							)
+						},
+						RouteCommandToInstanceFunc: func(dogma.Command) string {
+							return "<instance>"
+						},
+						HandleCommandFunc: func(
+							_ *AggregateRootStub,
+							s dogma.AggregateCommandScope[*AggregateRootStub],
+							_ dogma.Command,
+						) {
+							s.RecordEvent(EventA1)
+						},
+					}),
+				)
+			},
+		}
+
+		test := Begin(&testingmock.T{}, app).
+			Prepare(ExecuteCommand(CommandA1))
+
+		r, ok := test.AggregateRoot("<aggregate>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{EventA1},
+			}),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected instance IDs",
+			test.AggregateInstanceIDs("<aggregate>"),
+			[]string{"<instance>"},
+		)
+	})
+}
+
+func TestTest_ProcessRoot(t *testing.T) {
+	t.Run("it returns the root of the process instance", func(t *testing.T) {
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "6f4c3a1e-9d8b-4a7c-8e2f-1b3d5c7e9f02")
+				c.Routes(
+					dogma.ViaProcess(&ProcessMessageHandlerStub[*ProcessRootStub]{
+						ConfigureFunc: func(c dogma.ProcessConfigurer) {
+							c.Identity("<process>", "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c03")
+							c.Routes(
+								dogma.HandlesEvent[*EventStub[TypeA]](),
+								dogma.ExecutesCommand[*CommandStub[TypeA]](),
+							)
+						},
+						RouteEventToInstanceFunc: func(context.Context, dogma.Event) (string, bool, error) {
+							return "<instance>", true, nil
+						},
+						HandleEventFunc: func(
+							_ context.Context,
+							_ *ProcessRootStub,
+							s dogma.ProcessEventScope[*ProcessRootStub],
+							_ dogma.Event,
+						) error {
+							s.Mutate(func(r *ProcessRootStub) {
+								r.Value = "<value>"
+							})
+							return nil
+						},
+					}),
+				)
+			},
+		}
+
+		test := Begin(&testingmock.T{}, app).
+			Prepare(RecordEvent(EventA1))
+
+		r, ended, ok := test.ProcessRoot("<process>", "<instance>")
+		if !ok {
+			t.Fatal("expected instance to exist")
+		}
+
+		xtesting.Expect(t, "unexpected ended flag", ended, false)
+		xtesting.Expect(
+			t,
+			"unexpected root",
+			r,
+			dogma.ProcessRoot(&ProcessRootStub{Value: "<value>"}),
+		)
+
+		xtesting.Expect(
+			t,
+			"unexpected instance IDs",
+			test.ProcessInstanceIDs("<process>"),
+			[]string{"<instance>"},
+		)
+	})
+}
+
+func TestTest_Fork(t *testing.T) {
+	app := &ApplicationStub{
+		ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+			c.Identity("<app>", "0e8a1d8c-7b0e-4f43-9d8e-4c2a0f2b3e11")
+			c.Routes(
+				dogma.ViaAggregate(&AggregateMessageHandlerStub[*AggregateRootStub]{
+					ConfigureFunc: func(c dogma.AggregateConfigurer) {
+						c.Identity("<aggregate>", "3b0f5d8e-2a2b-4d6c-9f4a-6d1e7c8b9a01")
+						c.Routes(
+							dogma.HandlesCommand[*CommandStub[TypeA]](),
+							dogma.RecordsEvent[*EventStub[TypeA]](),
+						)
+					},
+					RouteCommandToInstanceFunc: func(dogma.Command) string {
+						return "<instance>"
+					},
+					HandleCommandFunc: func(
+						_ *AggregateRootStub,
+						s dogma.AggregateCommandScope[*AggregateRootStub],
+						m dogma.Command,
+					) {
+						s.RecordEvent(&EventStub[TypeA]{
+							Content: m.(*CommandStub[TypeA]).Content,
+						})
+					},
+				}),
+			)
+		},
+	}
+
+	t.Run("it isolates the state of each test", func(t *testing.T) {
+		test := Begin(&testingmock.T{}, app).
+			Prepare(ExecuteCommand(CommandA1))
+
+		fork := test.Fork(&testingmock.T{}).
+			Prepare(ExecuteCommand(CommandA2))
+
+		test.Prepare(ExecuteCommand(CommandA3))
+
+		r, _ := test.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root in original test",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{EventA1, EventA3},
+			}),
+		)
+
+		r, _ = fork.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root in forked test",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{EventA1, EventA2},
+			}),
+		)
+	})
+
+	t.Run("it does not share a file event store with the original test", func(t *testing.T) {
+		store := engine.NewFileEventStore(t.TempDir())
+
+		test := Begin(
+			&testingmock.T{},
+			app,
+			WithUnsafeEngineOptions(engine.WithEventStore(store)),
+		).Prepare(ExecuteCommand(CommandA1))
+
+		test.Fork(&testingmock.T{}).
+			Prepare(ExecuteCommand(CommandA2))
+
+		r, _ := test.AggregateRoot("<aggregate>", "<instance>")
+		xtesting.Expect(
+			t,
+			"unexpected root in original test",
+			r,
+			dogma.AggregateRoot(&AggregateRootStub{
+				AppliedEvents: []dogma.Event{EventA1},
+			}),
+		)
+	})
+
+	t.Run("it does not share fault state with the original test", func(t *testing.T) {
+		test := Begin(
+			&testingmock.T{},
+			app,
+			WithUnsafeOperationOptions(
+				engine.FailHandler(
+					"<aggregate>",
+					errors.New("<error>"),
+					engine.OnInvocation(2),
+				),
+			),
+		).Prepare(ExecuteCommand(CommandA1))
+
+		ft := &testingmock.T{FailSilently: true}
+		test.Fork(ft).Prepare(ExecuteCommand(CommandA2))
+
+		mt := &testingmock.T{FailSilently: true}
+		test.Fork(mt).Prepare(ExecuteCommand(CommandA2))
+
+		if !ft.Failed() {
+			t.Fatal("expected the fault to be injected into the first fork")
+		}
+
+		if !mt.Failed() {
+			t.Fatal("expected the fault to be injected into the second fork")
+		}
+	})
+
+	t.Run("it logs to the forked test's TestingT", func(t *testing.T) {
+		mt := &testingmock.T{}
+		test := Begin(mt, app)
+
+		ft := &testingmock.T{}
+		test.Fork(ft).Prepare(ExecuteCommand(CommandA1))
+
+		if len(mt.Logs) != 0 {
+			t.Fatal("did not expect any output from the original test")
+		}
+
+		if len(ft.Logs) == 0 {
+			t.Fatal("expected output from the forked test")
+		}
+	})
+
+	t.Run("it isolates the virtual clock of each test", func(t *testing.T) {
+		st := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		test := Begin(&testingmock.T{}, app, StartTimeAt(st)).
+			Prepare(AdvanceTime(ByDuration(time.Hour)))
+
+		ft := &testingmock.T{}
+		fork := test.Fork(ft)
+
+		test.Prepare(AdvanceTime(ToTime(st.Add(3 * time.Hour))))
+		fork.Prepare(AdvanceTime(ToTime(st.Add(2 * time.Hour))))
+
+		if ft.Failed() {
+			t.Fatal("expected the forked test's clock to be unaffected by the original test")
+		}
+	})
+}
@@ -40,3 +40,53 @@ func WithUnsafeOperationOptions(options ...engine.OperationOption) TestOption {
 		t.operationOptions = append(t.operationOptions, options...)
 	})
 }
+
+// WithUnsafeEngineOptions returns a TestOption that applies a set of engine
+// options when the test's engine is created.
+//
+// This function is provided for forward-compatibility with engine options and
+// for low level control of the engine's behavior.
+//
+// The provided options may override options that the Test sets during its
+// normal operation and should be used with caution.
+func WithUnsafeEngineOptions(options ...engine.Option) TestOption {
+	return testOptionFunc(func(t *Test) {
+		t.engineOptions = append(t.engineOptions, options...)
+	})
+}
+
+// WithHandlerTimeout returns a test option that cancels the context passed to
+// each call to a handler once the timeout has elapsed.
+//
+// It prevents a handler that blocks, such as an integration that performs I/O,
+// from hanging the test indefinitely. See engine.WithHandlerTimeout().
+func WithHandlerTimeout(ht engine.HandlerTimeout) TestOption {
+	opt := engine.WithHandlerTimeout(ht)
+
+	return testOptionFunc(func(t *Test) {
+		t.engineOptions = append(t.engineOptions, opt)
+	})
+}
+
+// WarnOnSlowHandlers returns a test option that logs a warning whenever a call
+// to a handler takes longer than threshold, as measured by the wall clock.
+func WarnOnSlowHandlers(threshold time.Duration) TestOption {
+	opt := engine.WithSlowHandlerThreshold(threshold)
+
+	return testOptionFunc(func(t *Test) {
+		t.engineOptions = append(t.engineOptions, opt)
+		t.failOnSlowHandlers = false
+	})
+}
+
+// FailOnSlowHandlers returns a test option that causes the test to fail
+// whenever a call to a handler takes longer than threshold, as measured by the
+// wall clock.
+func FailOnSlowHandlers(threshold time.Duration) TestOption {
+	opt := engine.WithSlowHandlerThreshold(threshold)
+
+	return testOptionFunc(func(t *Test) {
+		t.engineOptions = append(t.engineOptions, opt)
+		t.failOnSlowHandlers = true
+	})
+}
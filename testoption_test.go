@@ -2,12 +2,16 @@ package testkit_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/config"
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	. "github.com/dogmatiq/testkit"
+	"github.com/dogmatiq/testkit/engine"
+	"github.com/dogmatiq/testkit/envelope"
 	"github.com/dogmatiq/testkit/internal/testingmock"
 	"github.com/dogmatiq/testkit/internal/x/xtesting"
 )
@@ -103,3 +107,177 @@ func TestWithMessageComparatorOption(t *testing.T) {
 			)
 	})
 }
+
+func TestWithUnsafeEngineOptions(t *testing.T) {
+	t.Run("it applies the options to the test's engine", func(t *testing.T) {
+		handler := &IntegrationMessageHandlerStub{
+			ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+				c.Identity("<handler-name>", "9e7c2cf3-a9e0-4d51-b1b8-fb7e30a40e34")
+				c.Routes(
+					dogma.HandlesCommand[*CommandStub[TypeA]](),
+				)
+			},
+		}
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "6a4c1d6e-4ac8-4a43-9f5e-1d6ee0f0e6a1")
+				c.Routes(
+					dogma.ViaIntegration(handler),
+				)
+			},
+		}
+
+		var handlers []string
+		Begin(
+			&testingmock.T{},
+			app,
+			WithUnsafeEngineOptions(
+				engine.WithInterceptor(&handlerRecorder{&handlers}),
+			),
+		).
+			EnableHandlers("<handler-name>").
+			Prepare(ExecuteCommand(CommandA1))
+
+		xtesting.Expect(t, "unexpected handlers", handlers, []string{"<handler-name>"})
+	})
+}
+
+// handlerRecorder is an engine.Interceptor that records the names of the
+// handlers that handle messages.
+type handlerRecorder struct {
+	handlers *[]string
+}
+
+func (r *handlerRecorder) InterceptHandle(
+	ctx context.Context,
+	h config.Handler,
+	env *envelope.Envelope,
+	_ time.Time,
+	next engine.HandleFunc,
+) ([]*envelope.Envelope, error) {
+	*r.handlers = append(*r.handlers, h.Identity().GetName())
+	return next(ctx, env)
+}
+
+func (r *handlerRecorder) InterceptTick(
+	ctx context.Context,
+	_ config.Handler,
+	_ time.Time,
+	next engine.TickFunc,
+) ([]*envelope.Envelope, error) {
+	return next(ctx)
+}
+
+func TestWithHandlerTimeout(t *testing.T) {
+	t.Run("it cancels the context of handlers that take too long", func(t *testing.T) {
+		handler := &IntegrationMessageHandlerStub{
+			ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+				c.Identity("<handler-name>", "0e5f6d4a-9a1b-4a58-8f5b-2b6e0c3d1f7e")
+				c.Routes(
+					dogma.HandlesCommand[*CommandStub[TypeA]](),
+				)
+			},
+			HandleCommandFunc: func(
+				ctx context.Context,
+				_ dogma.IntegrationCommandScope,
+				_ dogma.Command,
+			) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+
+		app := &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "7f2a4c8e-3b1d-4e6f-9a0c-5d8b2e1f4a7c")
+				c.Routes(
+					dogma.ViaIntegration(handler),
+				)
+			},
+		}
+
+		mt := &testingmock.T{FailSilently: true}
+
+		Begin(
+			mt,
+			app,
+			WithHandlerTimeout(engine.HandlerTimeout{Duration: 10 * time.Millisecond}),
+		).
+			EnableHandlers("<handler-name>").
+			Prepare(ExecuteCommand(CommandA1))
+
+		if !mt.Failed() {
+			t.Fatal("expected test to fail")
+		}
+
+		xtesting.ExpectContains(
+			t,
+			"expected the timeout to be logged",
+			mt.Logs,
+			"<handler-name> integration: context deadline exceeded",
+		)
+	})
+}
+
+func TestSlowHandlerOptions(t *testing.T) {
+	newApp := func() dogma.Application {
+		handler := &IntegrationMessageHandlerStub{
+			ConfigureFunc: func(c dogma.IntegrationConfigurer) {
+				c.Identity("<handler-name>", "4c9e2b7a-1d3f-4a8e-b6c5-0f2d9e7a3b1c")
+				c.Routes(
+					dogma.HandlesCommand[*CommandStub[TypeA]](),
+				)
+			},
+			HandleCommandFunc: func(
+				context.Context,
+				dogma.IntegrationCommandScope,
+				dogma.Command,
+			) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			},
+		}
+
+		return &ApplicationStub{
+			ConfigureFunc: func(c dogma.ApplicationConfigurer) {
+				c.Identity("<app>", "a3e8d1c6-5b2f-4e9a-8c7d-1f6b4a2e9d3c")
+				c.Routes(
+					dogma.ViaIntegration(handler),
+				)
+			},
+		}
+	}
+
+	t.Run("WarnOnSlowHandlers() logs slow handlers without failing the test", func(t *testing.T) {
+		mt := &testingmock.T{FailSilently: true}
+
+		Begin(mt, newApp(), WarnOnSlowHandlers(time.Millisecond)).
+			EnableHandlers("<handler-name>").
+			Prepare(ExecuteCommand(CommandA1))
+
+		if mt.Failed() {
+			t.Fatal("did not expect the test to fail")
+		}
+
+		for _, l := range mt.Logs {
+			if strings.Contains(l, "slow handling took") {
+				return
+			}
+		}
+
+		t.Fatal("expected the slow handler to be logged")
+	})
+
+	t.Run("FailOnSlowHandlers() fails the test", func(t *testing.T) {
+		mt := &testingmock.T{FailSilently: true}
+
+		Begin(mt, newApp(), FailOnSlowHandlers(time.Millisecond)).
+			EnableHandlers("<handler-name>").
+			Prepare(ExecuteCommand(CommandA1))
+
+		if !mt.Failed() {
+			t.Fatal("expected test to fail")
+		}
+	})
+}